@@ -0,0 +1,413 @@
+package tsgmysqlutils
+
+/*
+ Typed database aggregate functions, eg: SUM(*), AVG(*), MAX(created_time) etc
+  Usage:
+	avg, err := client.QueryAggregateFloat("SELECT AVG(stature) FROM we_test_tab1")
+	total, err := client.QueryAggregateDecimal("SELECT SUM(amount) FROM orders")
+	latest, err := client.QueryAggregateTime("SELECT MAX(created_time) FROM we_test_tab1")
+
+	// NULL if no rows are summed
+	sum, err := client.QueryAggregateNull("SELECT SUM(weight) FROM we_test_tab1 WHERE gender = ?", 3)
+	if err == nil && sum.Valid {
+		weight, err := sum.Float64()
+	}
+
+	var stats struct {
+		Count int64     `column:"count"`
+		Avg   float64   `column:"avg"`
+		Last  time.Time `column:"last"`
+	}
+	err := client.QueryAggregateStruct(&stats, "SELECT COUNT(*) AS count, AVG(weight) AS avg, MAX(created_time) AS last FROM we_test_tab1")
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  The aggregate is NULL, eg: SUM(*) of no rows, use the QueryAggregateNull functions for the nullable aggregates
+*/
+var ErrNullAggregate = errors.New(MySQL + " aggregate is NULL")
+
+/*
+  The layouts of the MySQL DATETIME, TIMESTAMP and DATE values
+*/
+const (
+	mysqlDatetimeLayout = "2006-01-02 15:04:05.999999999"
+	mysqlDateLayout     = "2006-01-02"
+)
+
+/*
+  A nullable aggregate value, convert it by the typed functions
+*/
+type AggregateValue struct {
+	// int64, float64, []byte, string or time.Time of the driver
+	Value interface{}
+	// false if NULL
+	Valid bool
+	// the location of the DATETIME values, if nil, UTC
+	loc *time.Location
+}
+
+/*
+  Implement sql.Scanner
+*/
+func (value *AggregateValue) Scan(src interface{}) error {
+	if bytes, ok := src.([]byte); ok {
+		// the driver reuses the buffer
+		src = append([]byte(nil), bytes...)
+	}
+	value.Value = src
+	value.Valid = src != nil
+	return nil
+}
+
+func (value AggregateValue) Int64() (int64, error) {
+	switch v := value.Value.(type) {
+	case nil:
+		return 0, ErrNullAggregate
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, value.convertError("int64")
+}
+
+func (value AggregateValue) Float64() (float64, error) {
+	switch v := value.Value.(type) {
+	case nil:
+		return 0, ErrNullAggregate
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, value.convertError("float64")
+}
+
+/*
+  The exact decimal string, eg: SUM(amount) of a DECIMAL(20,2) column: "1024.50"
+*/
+func (value AggregateValue) Decimal() (string, error) {
+	switch v := value.Value.(type) {
+	case nil:
+		return "", ErrNullAggregate
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	}
+	return "", value.convertError("decimal")
+}
+
+/*
+  The DATETIME, TIMESTAMP or DATE value, parsed in the client location if the DSN has no parseTime=true,
+  the zero value of "0000-00-00 00:00:00"
+*/
+func (value AggregateValue) Time() (time.Time, error) {
+	var text string
+	switch v := value.Value.(type) {
+	case nil:
+		return time.Time{}, ErrNullAggregate
+	case time.Time:
+		return v, nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return time.Time{}, value.convertError("time.Time")
+	}
+	return parseTime(text, value.loc)
+}
+
+/*
+  Parse a DATETIME, TIMESTAMP or DATE text in the location, if nil, UTC, "0000-00-00 00:00:00": the zero value
+*/
+func parseTime(text string, loc *time.Location) (time.Time, error) {
+	if strings.HasPrefix(text, "0000-00-00") {
+		return time.Time{}, nil
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	layout := mysqlDatetimeLayout
+	if len(text) == len(mysqlDateLayout) {
+		layout = mysqlDateLayout
+	}
+	return time.ParseInLocation(layout, text, loc)
+}
+
+func (value AggregateValue) convertError(typeName string) error {
+	return fmt.Errorf("%s converting aggregate %T to %s is unsupported", MySQL, value.Value, typeName)
+}
+
+/*
+  Get the location of the DATETIME values: local if IsLocalTime, otherwise UTC
+*/
+func (client *DBClient) timeLocation() *time.Location {
+	if client.Config.IsLocalTime {
+		return time.Local
+	}
+	return time.UTC
+}
+
+/*
+  Query the aggregate value of the first column of the first row, tx: if nil, not in a transaction
+*/
+func (client *DBClient) queryAggregate(ctx context.Context, tx *db.Tx, sql string, args []interface{}) (value AggregateValue, err error) {
+	var row *db.Row
+	if tx == nil {
+		row, err = client.QueryRowContext(ctx, nil, sql, args...)
+	} else {
+		row, err = client.TxQueryRowContext(ctx, tx, nil, sql, args...)
+	}
+	if err != nil {
+		return value, err
+	}
+	value.loc = client.timeLocation()
+	err = row.Scan(&value)
+	if err != nil {
+		return value, client.scanError(err, sql, args...)
+	}
+	return value, nil
+}
+
+/*
+  Log and wrap the scan error, sql.ErrNoRows is returned unchanged
+*/
+func (client *DBClient) scanError(err error, sql string, args ...interface{}) error {
+	if err == db.ErrNoRows {
+		return err
+	}
+	client.logErrorSql(err, sql, args...)
+	return wrapSqlError(OpQueryRow, sql, err)
+}
+
+/*
+ Database aggregate function, eg: AVG(*) etc
+*/
+func (client *DBClient) QueryAggregateFloat(sql string, args ...interface{}) (aggregate float64, err error) {
+	return client.QueryAggregateFloatContext(context.Background(), sql, args...)
+}
+
+/*
+ Database aggregate function, eg: AVG(*) etc,context
+*/
+func (client *DBClient) QueryAggregateFloatContext(ctx context.Context, sql string, args ...interface{}) (aggregate float64, err error) {
+	value, err := client.queryAggregate(ctx, nil, sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float64()
+}
+
+/*
+ Database aggregate function, eg: SUM(amount) of a DECIMAL column etc
+*/
+func (client *DBClient) QueryAggregateDecimal(sql string, args ...interface{}) (aggregate string, err error) {
+	return client.QueryAggregateDecimalContext(context.Background(), sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(amount) of a DECIMAL column etc,context
+*/
+func (client *DBClient) QueryAggregateDecimalContext(ctx context.Context, sql string, args ...interface{}) (aggregate string, err error) {
+	value, err := client.queryAggregate(ctx, nil, sql, args)
+	if err != nil {
+		return "", err
+	}
+	return value.Decimal()
+}
+
+/*
+ Database aggregate function, eg: MAX(created_time) etc
+*/
+func (client *DBClient) QueryAggregateTime(sql string, args ...interface{}) (aggregate time.Time, err error) {
+	return client.QueryAggregateTimeContext(context.Background(), sql, args...)
+}
+
+/*
+ Database aggregate function, eg: MAX(created_time) etc,context
+*/
+func (client *DBClient) QueryAggregateTimeContext(ctx context.Context, sql string, args ...interface{}) (aggregate time.Time, err error) {
+	value, err := client.queryAggregate(ctx, nil, sql, args)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return value.Time()
+}
+
+/*
+ Database nullable aggregate function, eg: SUM(*) of no rows etc
+*/
+func (client *DBClient) QueryAggregateNull(sql string, args ...interface{}) (aggregate AggregateValue, err error) {
+	return client.QueryAggregateNullContext(context.Background(), sql, args...)
+}
+
+/*
+ Database nullable aggregate function, eg: SUM(*) of no rows etc,context
+*/
+func (client *DBClient) QueryAggregateNullContext(ctx context.Context, sql string, args ...interface{}) (aggregate AggregateValue, err error) {
+	return client.queryAggregate(ctx, nil, sql, args)
+}
+
+/*
+ Database multiple aggregate functions, scan the columns into the dest struct fields by the "column" tags
+*/
+func (client *DBClient) QueryAggregateStruct(dest interface{}, sql string, args ...interface{}) error {
+	return client.QueryAggregateStructContext(context.Background(), dest, sql, args...)
+}
+
+/*
+ Database multiple aggregate functions, scan the columns into the dest struct fields by the "column" tags,context
+*/
+func (client *DBClient) QueryAggregateStructContext(ctx context.Context, dest interface{}, sql string, args ...interface{}) error {
+	rows, err := client.QueryListContext(ctx, nil, sql, args...)
+	if err != nil {
+		return err
+	}
+	return client.scanAggregateStruct(rows, dest, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: AVG(*) etc,transaction
+*/
+func (client *DBClient) TxQueryAggregateFloat(tx *db.Tx, sql string, args ...interface{}) (aggregate float64, err error) {
+	return client.TxQueryAggregateFloatContext(context.Background(), tx, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: AVG(*) etc,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateFloatContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (aggregate float64, err error) {
+	value, err := client.queryAggregate(ctx, tx, sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float64()
+}
+
+/*
+ Database aggregate function, eg: SUM(amount) of a DECIMAL column etc,transaction
+*/
+func (client *DBClient) TxQueryAggregateDecimal(tx *db.Tx, sql string, args ...interface{}) (aggregate string, err error) {
+	return client.TxQueryAggregateDecimalContext(context.Background(), tx, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(amount) of a DECIMAL column etc,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateDecimalContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (aggregate string, err error) {
+	value, err := client.queryAggregate(ctx, tx, sql, args)
+	if err != nil {
+		return "", err
+	}
+	return value.Decimal()
+}
+
+/*
+ Database aggregate function, eg: MAX(created_time) etc,transaction
+*/
+func (client *DBClient) TxQueryAggregateTime(tx *db.Tx, sql string, args ...interface{}) (aggregate time.Time, err error) {
+	return client.TxQueryAggregateTimeContext(context.Background(), tx, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: MAX(created_time) etc,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateTimeContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (aggregate time.Time, err error) {
+	value, err := client.queryAggregate(ctx, tx, sql, args)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return value.Time()
+}
+
+/*
+ Database nullable aggregate function, eg: SUM(*) of no rows etc,transaction
+*/
+func (client *DBClient) TxQueryAggregateNull(tx *db.Tx, sql string, args ...interface{}) (aggregate AggregateValue, err error) {
+	return client.TxQueryAggregateNullContext(context.Background(), tx, sql, args...)
+}
+
+/*
+ Database nullable aggregate function, eg: SUM(*) of no rows etc,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateNullContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (aggregate AggregateValue, err error) {
+	return client.queryAggregate(ctx, tx, sql, args)
+}
+
+/*
+ Database multiple aggregate functions, scan the columns into the dest struct fields by the "column" tags,transaction
+*/
+func (client *DBClient) TxQueryAggregateStruct(tx *db.Tx, dest interface{}, sql string, args ...interface{}) error {
+	return client.TxQueryAggregateStructContext(context.Background(), tx, dest, sql, args...)
+}
+
+/*
+ Database multiple aggregate functions, scan the columns into the dest struct fields by the "column" tags,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateStructContext(ctx context.Context, tx *db.Tx, dest interface{}, sql string, args ...interface{}) error {
+	rows, err := client.TxQueryListContext(ctx, tx, nil, sql, args...)
+	if err != nil {
+		return err
+	}
+	return client.scanAggregateStruct(rows, dest, sql, args...)
+}
+
+/*
+  Scan the first row into the dest struct, see structPlan
+*/
+func (client *DBClient) scanAggregateStruct(rows *db.Rows, dest interface{}, sql string, args ...interface{}) error {
+	defer rows.Close()
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() || destValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%s aggregate dest must be a non-nil struct pointer, got %T", MySQL, dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return client.scanError(err, sql, args...)
+	}
+	scanner, err := client.newStructScanner(destValue.Elem().Type(), columns)
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return client.scanError(err, sql, args...)
+		}
+		return db.ErrNoRows
+	}
+	if err = scanner.scan(rows, destValue.Elem()); err != nil {
+		return client.scanError(err, sql, args...)
+	}
+	return nil
+}
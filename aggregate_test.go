@@ -0,0 +1,93 @@
+package tsgmysqlutils
+
+/*
+ Aggregate query test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"fmt"
+	"github.com/timespacegroup/go-utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectAggregateTypes(t *testing.T) {
+	client := TestDbClient()
+
+	stature, err := client.QueryAggregateFloat("SELECT AVG(stature) FROM we_test_tab1 WHERE is_deleted <> 1;")
+	tsgutils.Stdout("Select aggregate float: ", stature, err)
+	weight, err := client.QueryAggregateDecimal("SELECT SUM(weight) FROM we_test_tab1 WHERE is_deleted <> 1;")
+	tsgutils.Stdout("Select aggregate decimal: ", weight, err)
+	latest, err := client.QueryAggregateTime("SELECT MAX(created_time) FROM we_test_tab1 WHERE is_deleted <> 1;")
+	tsgutils.Stdout("Select aggregate time: ", latest, err)
+	sum, err := client.QueryAggregateNull("SELECT SUM(weight) FROM we_test_tab1 WHERE gender = ?;", 3)
+	tsgutils.Stdout("Select aggregate null: ", sum.Valid, err)
+
+	var stats struct {
+		Count int64     `column:"count"`
+		Avg   float64   `column:"avg"`
+		Last  time.Time `column:"last"`
+	}
+	err = client.QueryAggregateStruct(&stats, "SELECT COUNT(*) AS count, AVG(weight) AS avg, MAX(created_time) AS last FROM we_test_tab1;")
+	tsgutils.Stdout("Select aggregate struct: ", stats, err)
+	client.CloseConn()
+}
+
+func TestAggregateValue(t *testing.T) {
+	var value AggregateValue
+	buffer := []byte("1024.50")
+	value.Scan(buffer)
+	buffer[0] = '9'
+	if decimal, err := value.Decimal(); err != nil || decimal != "1024.50" {
+		t.Error("Aggregate decimal:", decimal, err)
+	}
+	if float, err := value.Float64(); err != nil || float != 1024.5 {
+		t.Error("Aggregate float:", float, err)
+	}
+	if _, err := value.Int64(); err == nil {
+		t.Error("Aggregate int64 of a decimal")
+	}
+
+	value.Scan(int64(7))
+	if i, err := value.Int64(); err != nil || i != 7 {
+		t.Error("Aggregate int64:", i, err)
+	}
+	if _, err := value.Time(); err == nil {
+		t.Error("Aggregate time of an int64")
+	}
+
+	value.Scan(nil)
+	if _, err := value.Int64(); value.Valid || err != ErrNullAggregate {
+		t.Error("Aggregate NULL:", err)
+	}
+
+	value.loc = time.Local
+	value.Scan([]byte("2018-04-19 13:20:09.123"))
+	expected := time.Date(2018, 4, 19, 13, 20, 9, 123000000, time.Local)
+	if tm, err := value.Time(); err != nil || !tm.Equal(expected) {
+		t.Error("Aggregate datetime:", tm, err)
+	}
+	value.Scan([]byte("2018-04-16"))
+	if tm, err := value.Time(); err != nil || !tm.Equal(time.Date(2018, 4, 16, 0, 0, 0, 0, time.Local)) {
+		t.Error("Aggregate date:", tm, err)
+	}
+	value.Scan([]byte("0000-00-00 00:00:00"))
+	if tm, err := value.Time(); err != nil || !tm.IsZero() {
+		t.Error("Aggregate zero datetime:", tm, err)
+	}
+
+	var stats struct {
+		Count  int64 `column:"count"`
+		Avg    float64
+		hidden int64
+	}
+	plan := getStructPlan(reflect.TypeOf(stats))
+	if fmt.Sprint(plan.fieldIndex("count")) != "[0]" || fmt.Sprint(plan.fieldIndex("AVG")) != "[1]" ||
+		plan.fieldIndex("Count") != nil || plan.fieldIndex("hidden") != nil {
+		t.Error("Aggregate field index")
+	}
+}
@@ -0,0 +1,1361 @@
+package tsgmysqlutils
+
+/*
+ Fluent sql builders, the identifiers are quoted by backticks, the values are the "?" args,
+ the statements are run by the DBClient or a Querier, the hooks and the slow sql log apply,
+ the tables and the columns must be identifiers, the expressions are passed by Expr and the *Raw methods
+  Usage:
+	rows, err := client.Select("id", "name").From("we_test_tab1").
+		Where(tsgmysqlutils.Eq("gender", 2), tsgmysqlutils.Or(tsgmysqlutils.Like("name", "t%"), tsgmysqlutils.Gt("id", 10))).
+		OrderBy("id DESC").Limit(10).QueryList(nil)
+
+	// the columns of the "column" tags
+	tab1s, err := tsgmysqlutils.SelectAll[WeTestTab1](client.Select().ColumnsOf(WeTestTab1{}).From("we_test_tab1").
+		Where(tsgmysqlutils.In("id", ids)))
+
+	id, err := client.Insert("we_test_tab1").ValuesOf(tab1).Omit("id").Exec()
+	affected, err := client.Update("we_test_tab1").Set("name", "tony").Where(tsgmysqlutils.Eq("id", 1)).Exec()
+	affected, err := client.Delete("we_test_tab1").Where(tsgmysqlutils.Eq("is_deleted", 1)).Limit(100).Tx(tx).Exec()
+
+	// in WithTx, the Tx or a DBClient is a Querier
+	err := client.WithTx(ctx, nil, func(tx *tsgmysqlutils.Tx) error {
+		_, err := client.Update("we_test_tab1").Set("name", "tony").Where(tsgmysqlutils.Eq("id", 1)).Querier(tx).ExecContext(ctx)
+		return err
+	})
+
+	sql, args, err := client.Select("gender").ColumnsRaw("COUNT(*) AS total").From("we_test_tab1").GroupBy("gender").
+		Having(tsgmysqlutils.Expr("COUNT(*) > ?", 10)).ToSql()
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+  A where or having condition, see Eq, In, Expr, And, Or etc
+*/
+type Cond struct {
+	// "AND" or "OR" of the conds, if empty, a single condition of the sql
+	op    string
+	conds []Cond
+	sql   string
+	args  []interface{}
+	// the raw sql of Expr, parenthesized in the groups
+	raw bool
+	// the invalid column
+	err error
+}
+
+/*
+  column = value, nil: column IS NULL
+*/
+func Eq(column string, value interface{}) Cond {
+	if value == nil {
+		return IsNull(column)
+	}
+	return compare(column, "=", value)
+}
+
+/*
+  column <> value, nil: column IS NOT NULL
+*/
+func Ne(column string, value interface{}) Cond {
+	if value == nil {
+		return IsNotNull(column)
+	}
+	return compare(column, "<>", value)
+}
+
+/*
+  column > value
+*/
+func Gt(column string, value interface{}) Cond {
+	return compare(column, ">", value)
+}
+
+/*
+  column >= value
+*/
+func Ge(column string, value interface{}) Cond {
+	return compare(column, ">=", value)
+}
+
+/*
+  column < value
+*/
+func Lt(column string, value interface{}) Cond {
+	return compare(column, "<", value)
+}
+
+/*
+  column <= value
+*/
+func Le(column string, value interface{}) Cond {
+	return compare(column, "<=", value)
+}
+
+/*
+  column LIKE pattern, eg: "t%"
+*/
+func Like(column string, pattern string) Cond {
+	return compare(column, "LIKE", pattern)
+}
+
+/*
+  column NOT LIKE pattern
+*/
+func NotLike(column string, pattern string) Cond {
+	return compare(column, "NOT LIKE", pattern)
+}
+
+/*
+  column IN (values), values: a slice or a single value, an empty slice matches no rows
+*/
+func In(column string, values interface{}) Cond {
+	return in(column, "IN", "0 = 1", values)
+}
+
+/*
+  column NOT IN (values), values: a slice or a single value, an empty slice matches all rows
+*/
+func NotIn(column string, values interface{}) Cond {
+	return in(column, "NOT IN", "1 = 1", values)
+}
+
+/*
+  column BETWEEN from AND to
+*/
+func Between(column string, from, to interface{}) Cond {
+	return columnCond(column, " BETWEEN ? AND ?", from, to)
+}
+
+/*
+  column IS NULL
+*/
+func IsNull(column string) Cond {
+	return columnCond(column, " IS NULL")
+}
+
+/*
+  column IS NOT NULL
+*/
+func IsNotNull(column string) Cond {
+	return columnCond(column, " IS NOT NULL")
+}
+
+/*
+  A raw sql condition of the "?" args, the identifiers are not quoted, eg: Expr("`age` > ? OR `vip` = 1", 18),
+  the slice args are not expanded, see In
+*/
+func Expr(sql string, args ...interface{}) Cond {
+	return Cond{sql: sql, args: args, raw: true}
+}
+
+/*
+  All the conditions, the empty conditions are skipped
+*/
+func And(conds ...Cond) Cond {
+	return Cond{op: "AND", conds: conds}
+}
+
+/*
+  Any of the conditions, the empty conditions are skipped
+*/
+func Or(conds ...Cond) Cond {
+	return Cond{op: "OR", conds: conds}
+}
+
+/*
+  The condition of the quoted column and the sql, the invalid column is an error of the builder
+*/
+func columnCond(column, sql string, args ...interface{}) Cond {
+	quoted, err := QuoteIdent(column)
+	if err != nil {
+		return Cond{err: err}
+	}
+	return Cond{sql: quoted + sql, args: args}
+}
+
+func compare(column, op string, value interface{}) Cond {
+	return columnCond(column, " "+op+" ?", value)
+}
+
+func in(column, op, empty string, values interface{}) Cond {
+	if !isSliceArg(values) {
+		return columnCond(column, " "+op+" (?)", values)
+	}
+	if _, err := QuoteIdent(column); err != nil {
+		return Cond{err: err}
+	}
+	value := reflect.ValueOf(values)
+	if value.Len() == 0 {
+		return Cond{sql: empty}
+	}
+	args := make([]interface{}, value.Len())
+	for i := range args {
+		args[i] = value.Index(i).Interface()
+	}
+	return columnCond(column, " "+op+" ("+placeholders(len(args))+")", args...)
+}
+
+/*
+  Get the first invalid column of the conditions
+*/
+func condsErr(conds []Cond) error {
+	for _, cond := range conds {
+		if cond.err != nil {
+			return cond.err
+		}
+		if err := condsErr(cond.conds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cond Cond) isEmpty() bool {
+	if cond.err != nil {
+		return false
+	}
+	if cond.op == "" {
+		return cond.sql == ""
+	}
+	for _, c := range cond.conds {
+		if !c.isEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+  Append the sql and args of the condition, nested: inside a group, the groups and the raw sqls are parenthesized
+*/
+func (cond Cond) build(builder *strings.Builder, args *[]interface{}, nested bool) {
+	if cond.op == "" {
+		if cond.raw && nested {
+			builder.WriteString("(" + cond.sql + ")")
+		} else {
+			builder.WriteString(cond.sql)
+		}
+		*args = append(*args, cond.args...)
+		return
+	}
+	var conds []Cond
+	for _, c := range cond.conds {
+		if !c.isEmpty() {
+			conds = append(conds, c)
+		}
+	}
+	if len(conds) == 1 {
+		conds[0].build(builder, args, nested)
+		return
+	}
+	if nested {
+		builder.WriteByte('(')
+	}
+	for i, c := range conds {
+		if i > 0 {
+			builder.WriteString(" " + cond.op + " ")
+		}
+		c.build(builder, args, true)
+	}
+	if nested {
+		builder.WriteByte(')')
+	}
+}
+
+/*
+  Quote the identifier by backticks: "name" => "`name`", "t.name" => "`t`.`name`", "t.*" => "`t`.*",
+  "we_test_tab1 t" and "we_test_tab1 AS t" are quoted by parts, the other names are errors, eg: "COUNT(*)",
+  the expressions are passed by Expr and the *Raw methods of the builders
+*/
+func QuoteIdent(name string) (string, error) {
+	parts := strings.Fields(name)
+	switch {
+	case len(parts) == 1 && isIdentPath(parts[0]):
+		return quoteIdentPath(parts[0]), nil
+	case len(parts) == 2 && isIdentPath(parts[0]) && isIdent(parts[1]):
+		return quoteIdentPath(parts[0]) + " `" + parts[1] + "`", nil
+	case len(parts) == 3 && isIdentPath(parts[0]) && strings.EqualFold(parts[1], "AS") && isIdent(parts[2]):
+		return quoteIdentPath(parts[0]) + " AS `" + parts[2] + "`", nil
+	}
+	return "", fmt.Errorf("%s %q is not an identifier, the expressions are passed by Expr or the *Raw methods", MySQL, name)
+}
+
+func isIdent(name string) bool {
+	if name == "" || !isNameStart(name[0]) && name[0] != '$' {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+  The dot separated identifiers, the last may be "*"
+*/
+func isIdentPath(name string) bool {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if !isIdent(part) && (part != "*" || i == 0 || i < len(parts)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteIdentPath(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = "`" + part + "`"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+/*
+  Quote the order by column, the ASC or DESC suffix is kept
+*/
+func quoteOrderBy(column string) (string, error) {
+	parts := strings.Fields(column)
+	if n := len(parts); n > 1 && (strings.EqualFold(parts[n-1], "ASC") || strings.EqualFold(parts[n-1], "DESC")) {
+		quoted, err := QuoteIdent(strings.Join(parts[:n-1], " "))
+		return quoted + " " + strings.ToUpper(parts[n-1]), err
+	}
+	return QuoteIdent(column)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func quoteIdents(names []string) ([]string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		var err error
+		if quoted[i], err = QuoteIdent(name); err != nil {
+			return nil, err
+		}
+	}
+	return quoted, nil
+}
+
+/*
+  Get the structs of the values: the structs, the struct pointers, or the slices of them
+*/
+func structValues(values []interface{}) ([]reflect.Value, error) {
+	var structs []reflect.Value
+	var add func(value reflect.Value) error
+	add = func(value reflect.Value) error {
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		switch {
+		case value.Kind() == reflect.Struct && !isScalarType(value.Type()):
+			structs = append(structs, value)
+		case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				if err := add(value.Index(i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%s %s is not a struct", MySQL, value.Type())
+		}
+		return nil
+	}
+	for _, value := range values {
+		if value == nil {
+			return nil, fmt.Errorf("%s nil is not a struct", MySQL)
+		}
+		if err := add(reflect.ValueOf(value)); err != nil {
+			return nil, err
+		}
+	}
+	return structs, nil
+}
+
+/*
+  Get the "column" tag columns of the struct type, except the omitted
+*/
+func structColumns(structType reflect.Type, omit []string) []string {
+	var columns []string
+	for _, column := range getStructPlan(structType).columns {
+		if !containsString(omit, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func structField(value reflect.Value, column string) (interface{}, error) {
+	index := getStructPlan(value.Type()).fieldIndex(column)
+	if index == nil {
+		return nil, fmt.Errorf("%s column '%s' has no field in %s", MySQL, column, value.Type())
+	}
+	return value.FieldByIndex(index).Interface(), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+  The common parts of the builders: the table, where, order by, limit and the transaction
+*/
+type builderBase struct {
+	client *DBClient
+	tx     *db.Tx
+	// run by the querier if not nil, see Querier
+	querier Querier
+	// the quoted table
+	table string
+	where []Cond
+	// the quoted order by columns and the raw sqls
+	orderBy []string
+	limit   int
+	offset  int
+	limited bool
+	// UPDATE or DELETE without WHERE, see All
+	all bool
+	// the first error of the builder methods
+	err error
+}
+
+/*
+  Keep the first error, it is returned by ToSql
+*/
+func (base *builderBase) setErr(err error) {
+	if base.err == nil {
+		base.err = err
+	}
+}
+
+func (base *builderBase) setTable(table string) {
+	if table == "" {
+		return
+	}
+	quoted, err := QuoteIdent(table)
+	base.setErr(err)
+	base.table = quoted
+}
+
+func (base *builderBase) addOrderBy(columns []string) {
+	for _, column := range columns {
+		quoted, err := quoteOrderBy(column)
+		if err != nil {
+			base.setErr(err)
+			return
+		}
+		base.orderBy = append(base.orderBy, quoted)
+	}
+}
+
+func (base *builderBase) addWhere(conds []Cond) {
+	base.where = append(base.where, conds...)
+}
+
+func (base *builderBase) addOr(conds []Cond) {
+	if len(base.where) == 0 {
+		base.where = conds
+		return
+	}
+	base.where = []Cond{Or(And(base.where...), And(conds...))}
+}
+
+func (base *builderBase) setLimit(limit int) {
+	base.limit = limit
+	base.limited = true
+}
+
+func (base *builderBase) check(statement string) error {
+	if base.err != nil {
+		return base.err
+	}
+	if base.table == "" {
+		return fmt.Errorf("%s %s has no table", MySQL, statement)
+	}
+	if err := condsErr(base.where); err != nil {
+		return err
+	}
+	// a forgotten or an empty Where must not change the whole table
+	if (statement == "UPDATE" || statement == "DELETE") && !base.all && And(base.where...).isEmpty() {
+		return fmt.Errorf("%s %s has no WHERE, call All to change all the rows", MySQL, statement)
+	}
+	return nil
+}
+
+func (base *builderBase) buildWhere(builder *strings.Builder, args *[]interface{}) {
+	if where := And(base.where...); !where.isEmpty() {
+		builder.WriteString(" WHERE ")
+		where.build(builder, args, false)
+	}
+}
+
+/*
+  Append the ORDER BY and LIMIT clauses
+*/
+func (base *builderBase) buildOrderBy(builder *strings.Builder, args *[]interface{}) {
+	if len(base.orderBy) > 0 {
+		builder.WriteString(" ORDER BY " + strings.Join(base.orderBy, ", "))
+	}
+	if base.limited {
+		builder.WriteString(" LIMIT ?")
+		*args = append(*args, base.limit)
+		if base.offset > 0 {
+			builder.WriteString(" OFFSET ?")
+			*args = append(*args, base.offset)
+		}
+	}
+}
+
+/*
+  Get the context, the client and the transaction to run the built sql, the built args are not bound again, see BindArgs
+*/
+func (base *builderBase) runner(ctx context.Context, sql string) (context.Context, *DBClient, *db.Tx, error) {
+	if base.querier == nil {
+		return contextWithBoundSql(ctx, sql), base.client, base.tx, nil
+	}
+	client, tx, err := querierClient(base.querier)
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	return contextWithBoundSql(ctx, sql), client, tx, nil
+}
+
+func (base *builderBase) exec(ctx context.Context, sql string, args []interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	ctx, client, tx, err := base.runner(ctx, sql)
+	if err != nil {
+		return 0, err
+	}
+	if tx == nil {
+		return client.ExecContext(ctx, sql, args...)
+	}
+	return client.TxExecContext(ctx, tx, sql, args...)
+}
+
+/*
+  The SELECT builder
+*/
+type SelectBuilder struct {
+	builderBase
+	distinct bool
+	// the quoted columns and the raw sqls
+	columns []string
+	joins   []Cond
+	// the quoted columns and the raw sqls
+	groupBy   []string
+	having    []Cond
+	forUpdate bool
+}
+
+/*
+  Select the columns, if none, "*"
+*/
+func (client *DBClient) Select(columns ...string) *SelectBuilder {
+	builder := &SelectBuilder{builderBase: builderBase{client: client}}
+	return builder.Columns(columns...)
+}
+
+/*
+  Add the columns, eg: "id", "t1.name", "t2.note AS remark"
+*/
+func (builder *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	quoted, err := quoteIdents(columns)
+	builder.setErr(err)
+	builder.columns = append(builder.columns, quoted...)
+	return builder
+}
+
+/*
+  Add the raw sql columns, unquoted, never the user input, eg: ColumnsRaw("COUNT(*) AS total")
+*/
+func (builder *SelectBuilder) ColumnsRaw(columns ...string) *SelectBuilder {
+	builder.columns = append(builder.columns, columns...)
+	return builder
+}
+
+/*
+  Add the "column" tag columns of the struct, eg: ColumnsOf(WeTestTab1{})
+*/
+func (builder *SelectBuilder) ColumnsOf(value interface{}) *SelectBuilder {
+	structs, err := structValues([]interface{}{value})
+	if err != nil {
+		builder.err = err
+		return builder
+	}
+	if len(structs) > 0 {
+		builder.Columns(structColumns(structs[0].Type(), nil)...)
+	}
+	return builder
+}
+
+/*
+  SELECT DISTINCT
+*/
+func (builder *SelectBuilder) Distinct() *SelectBuilder {
+	builder.distinct = true
+	return builder
+}
+
+/*
+  The table, eg: "we_test_tab1" or "we_test_tab1 t1"
+*/
+func (builder *SelectBuilder) From(table string) *SelectBuilder {
+	builder.setTable(table)
+	return builder
+}
+
+/*
+  INNER JOIN the table ON the raw sql condition, eg: Join("we_test_tab2 t2", "t2.tab1_id = t1.id")
+*/
+func (builder *SelectBuilder) Join(table, on string, args ...interface{}) *SelectBuilder {
+	return builder.join("JOIN", table, on, args)
+}
+
+/*
+  LEFT JOIN the table ON the raw sql condition
+*/
+func (builder *SelectBuilder) LeftJoin(table, on string, args ...interface{}) *SelectBuilder {
+	return builder.join("LEFT JOIN", table, on, args)
+}
+
+/*
+  RIGHT JOIN the table ON the raw sql condition
+*/
+func (builder *SelectBuilder) RightJoin(table, on string, args ...interface{}) *SelectBuilder {
+	return builder.join("RIGHT JOIN", table, on, args)
+}
+
+func (builder *SelectBuilder) join(join, table, on string, args []interface{}) *SelectBuilder {
+	quoted, err := QuoteIdent(table)
+	builder.setErr(err)
+	builder.joins = append(builder.joins, Cond{sql: " " + join + " " + quoted + " ON " + on, args: args})
+	return builder
+}
+
+/*
+  AND the conditions
+*/
+func (builder *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	builder.addWhere(conds)
+	return builder
+}
+
+/*
+  OR the conditions with the previous conditions: (previous) OR (conds)
+*/
+func (builder *SelectBuilder) Or(conds ...Cond) *SelectBuilder {
+	builder.addOr(conds)
+	return builder
+}
+
+/*
+  GROUP BY the columns
+*/
+func (builder *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	quoted, err := quoteIdents(columns)
+	builder.setErr(err)
+	builder.groupBy = append(builder.groupBy, quoted...)
+	return builder
+}
+
+/*
+  GROUP BY the raw sqls, unquoted, never the user input, eg: GroupByRaw("DATE(created_time)")
+*/
+func (builder *SelectBuilder) GroupByRaw(exprs ...string) *SelectBuilder {
+	builder.groupBy = append(builder.groupBy, exprs...)
+	return builder
+}
+
+/*
+  HAVING the conditions, AND the conditions
+*/
+func (builder *SelectBuilder) Having(conds ...Cond) *SelectBuilder {
+	builder.having = append(builder.having, conds...)
+	return builder
+}
+
+/*
+  ORDER BY the columns, eg: "id", "created_time DESC"
+*/
+func (builder *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	builder.addOrderBy(columns)
+	return builder
+}
+
+/*
+  ORDER BY the raw sqls, unquoted, never the user input, eg: OrderByRaw("FIELD(status, 2, 1, 0)")
+*/
+func (builder *SelectBuilder) OrderByRaw(exprs ...string) *SelectBuilder {
+	builder.orderBy = append(builder.orderBy, exprs...)
+	return builder
+}
+
+/*
+  LIMIT the rows
+*/
+func (builder *SelectBuilder) Limit(limit int) *SelectBuilder {
+	builder.setLimit(limit)
+	return builder
+}
+
+/*
+  OFFSET the rows, needs Limit
+*/
+func (builder *SelectBuilder) Offset(offset int) *SelectBuilder {
+	builder.offset = offset
+	return builder
+}
+
+/*
+  SELECT ... FOR UPDATE, in a transaction
+*/
+func (builder *SelectBuilder) ForUpdate() *SelectBuilder {
+	builder.forUpdate = true
+	return builder
+}
+
+/*
+  Run in the transaction
+*/
+func (builder *SelectBuilder) Tx(tx *db.Tx) *SelectBuilder {
+	builder.tx, builder.querier = tx, nil
+	return builder
+}
+
+/*
+  Run by the querier: a DBClient or a Tx, eg: the Tx of WithTx, the nested transactions included
+*/
+func (builder *SelectBuilder) Querier(querier Querier) *SelectBuilder {
+	builder.tx, builder.querier = nil, querier
+	return builder
+}
+
+/*
+  Get the sql and the args
+*/
+func (builder *SelectBuilder) ToSql() (string, []interface{}, error) {
+	if err := builder.check("SELECT"); err != nil {
+		return "", nil, err
+	}
+	if err := condsErr(builder.having); err != nil {
+		return "", nil, err
+	}
+	if builder.offset > 0 && !builder.limited {
+		return "", nil, fmt.Errorf("%s SELECT offset needs a limit", MySQL)
+	}
+	var args []interface{}
+	sql := strings.Builder{}
+	sql.WriteString("SELECT ")
+	if builder.distinct {
+		sql.WriteString("DISTINCT ")
+	}
+	if len(builder.columns) == 0 {
+		sql.WriteString("*")
+	} else {
+		sql.WriteString(strings.Join(builder.columns, ", "))
+	}
+	sql.WriteString(" FROM " + builder.table)
+	for _, join := range builder.joins {
+		join.build(&sql, &args, false)
+	}
+	builder.buildWhere(&sql, &args)
+	if len(builder.groupBy) > 0 {
+		sql.WriteString(" GROUP BY " + strings.Join(builder.groupBy, ", "))
+	}
+	if having := And(builder.having...); !having.isEmpty() {
+		sql.WriteString(" HAVING ")
+		having.build(&sql, &args, false)
+	}
+	builder.buildOrderBy(&sql, &args)
+	if builder.forUpdate {
+		sql.WriteString(" FOR UPDATE")
+	}
+	return sql.String(), args, nil
+}
+
+/*
+  Get a row, see DBClient.QueryRow
+*/
+func (builder *SelectBuilder) QueryRow(orm ORMBase) (*db.Row, error) {
+	return builder.QueryRowContext(context.Background(), orm)
+}
+
+/*
+  Get a row,context
+*/
+func (builder *SelectBuilder) QueryRowContext(ctx context.Context, orm ORMBase) (*db.Row, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return client.QueryRowContext(ctx, orm, sql, args...)
+	}
+	return client.TxQueryRowContext(ctx, tx, orm, sql, args...)
+}
+
+/*
+  Get the rows, see DBClient.QueryList
+*/
+func (builder *SelectBuilder) QueryList(orm ORMBase) (*db.Rows, error) {
+	return builder.QueryListContext(context.Background(), orm)
+}
+
+/*
+  Get the rows,context
+*/
+func (builder *SelectBuilder) QueryListContext(ctx context.Context, orm ORMBase) (*db.Rows, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return client.QueryListContext(ctx, orm, sql, args...)
+	}
+	return client.TxQueryListContext(ctx, tx, orm, sql, args...)
+}
+
+/*
+  Get the aggregate of the single column, eg: Select("COUNT(*)"), see DBClient.QueryAggregate
+*/
+func (builder *SelectBuilder) QueryAggregate() (int64, error) {
+	return builder.QueryAggregateContext(context.Background())
+}
+
+/*
+  Get the aggregate of the single column,context
+*/
+func (builder *SelectBuilder) QueryAggregateContext(ctx context.Context) (int64, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return 0, err
+	}
+	value, err := client.queryAggregate(ctx, tx, sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return value.Int64()
+}
+
+/*
+  Get the rows as the maps, see DBClient.QueryMaps
+*/
+func (builder *SelectBuilder) QueryMaps() ([]map[string]interface{}, error) {
+	return builder.QueryMapsContext(context.Background())
+}
+
+/*
+  Get the rows as the maps,context
+*/
+func (builder *SelectBuilder) QueryMapsContext(ctx context.Context) ([]map[string]interface{}, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	records, err := client.queryRecords(ctx, tx, sql, args)
+	return recordMaps(records), err
+}
+
+/*
+  Get the first row as T, see QueryOne
+*/
+func SelectOne[T any](builder *SelectBuilder) (T, error) {
+	return SelectOneContext[T](context.Background(), builder)
+}
+
+/*
+  Get the first row as T,context
+*/
+func SelectOneContext[T any](ctx context.Context, builder *SelectBuilder) (value T, err error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return value, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return value, err
+	}
+	return queryOne[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get all rows as T, see QueryAll
+*/
+func SelectAll[T any](builder *SelectBuilder) ([]T, error) {
+	return SelectAllContext[T](context.Background(), builder)
+}
+
+/*
+  Get all rows as T,context
+*/
+func SelectAllContext[T any](ctx context.Context, builder *SelectBuilder) ([]T, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	ctx, client, tx, err := builder.runner(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return queryAll[T](ctx, client, tx, sql, args)
+}
+
+/*
+  The INSERT builder
+*/
+type InsertBuilder struct {
+	builderBase
+	columns []string
+	omit    []string
+	rows    [][]interface{}
+	structs []reflect.Value
+}
+
+/*
+  Insert into the table
+*/
+func (client *DBClient) Insert(table string) *InsertBuilder {
+	builder := &InsertBuilder{builderBase: builderBase{client: client}}
+	builder.setTable(table)
+	return builder
+}
+
+/*
+  The columns of the values, if none, the "column" tag columns of the ValuesOf structs
+*/
+func (builder *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	builder.columns = append(builder.columns, columns...)
+	return builder
+}
+
+/*
+  Add a row of the values of the columns
+*/
+func (builder *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	builder.rows = append(builder.rows, values)
+	return builder
+}
+
+/*
+  Add the rows of the structs: the structs, the struct pointers, or the slices of them
+*/
+func (builder *InsertBuilder) ValuesOf(values ...interface{}) *InsertBuilder {
+	structs, err := structValues(values)
+	if err != nil {
+		builder.err = err
+	}
+	builder.structs = append(builder.structs, structs...)
+	return builder
+}
+
+/*
+  Omit the "column" tag columns of the ValuesOf structs, eg: the auto increment "id"
+*/
+func (builder *InsertBuilder) Omit(columns ...string) *InsertBuilder {
+	builder.omit = append(builder.omit, columns...)
+	return builder
+}
+
+/*
+  Run in the transaction
+*/
+func (builder *InsertBuilder) Tx(tx *db.Tx) *InsertBuilder {
+	builder.tx, builder.querier = tx, nil
+	return builder
+}
+
+/*
+  Run by the querier: a DBClient or a Tx, eg: the Tx of WithTx, the nested transactions included
+*/
+func (builder *InsertBuilder) Querier(querier Querier) *InsertBuilder {
+	builder.tx, builder.querier = nil, querier
+	return builder
+}
+
+/*
+  Get the sql and the args
+*/
+func (builder *InsertBuilder) ToSql() (string, []interface{}, error) {
+	if err := builder.check("INSERT"); err != nil {
+		return "", nil, err
+	}
+	columns := builder.columns
+	if len(columns) == 0 && len(builder.structs) > 0 {
+		columns = structColumns(builder.structs[0].Type(), builder.omit)
+	}
+	if len(columns) == 0 || len(builder.rows)+len(builder.structs) == 0 {
+		return "", nil, fmt.Errorf("%s INSERT has no columns or values", MySQL)
+	}
+	var args []interface{}
+	for _, row := range builder.rows {
+		if len(row) != len(columns) {
+			return "", nil, fmt.Errorf("%s INSERT %d values of %d columns", MySQL, len(row), len(columns))
+		}
+		args = append(args, row...)
+	}
+	for _, value := range builder.structs {
+		for _, column := range columns {
+			arg, err := structField(value, column)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+	quoted, err := quoteIdents(columns)
+	if err != nil {
+		return "", nil, err
+	}
+	row := "(" + placeholders(len(columns)) + ")"
+	rows := strings.TrimSuffix(strings.Repeat(row+", ", len(args)/len(columns)), ", ")
+	return "INSERT INTO " + builder.table + " (" + strings.Join(quoted, ", ") + ") VALUES " + rows, args, nil
+}
+
+/*
+  Insert the rows, return the last insert id, see DBClient.Exec
+*/
+func (builder *InsertBuilder) Exec() (int64, error) {
+	return builder.ExecContext(context.Background())
+}
+
+/*
+  Insert the rows,context
+*/
+func (builder *InsertBuilder) ExecContext(ctx context.Context) (int64, error) {
+	sql, args, err := builder.ToSql()
+	return builder.exec(ctx, sql, args, err)
+}
+
+/*
+  The UPDATE builder
+*/
+type UpdateBuilder struct {
+	builderBase
+	sets []Cond
+	// the columns of the sets
+	columns []string
+	omit    []string
+	// the SetOf struct, if invalid, none
+	value reflect.Value
+}
+
+/*
+  Update the table
+*/
+func (client *DBClient) Update(table string) *UpdateBuilder {
+	builder := &UpdateBuilder{builderBase: builderBase{client: client}}
+	builder.setTable(table)
+	return builder
+}
+
+/*
+  SET column = value
+*/
+func (builder *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	return builder.SetExpr(column, "?", value)
+}
+
+/*
+  SET column = the raw sql of the "?" args, eg: SetExpr("count", "`count` + ?", 1)
+*/
+func (builder *UpdateBuilder) SetExpr(column, expr string, args ...interface{}) *UpdateBuilder {
+	set := columnCond(column, " = "+expr, args...)
+	builder.setErr(set.err)
+	builder.sets = append(builder.sets, set)
+	builder.columns = append(builder.columns, column)
+	return builder
+}
+
+/*
+  SET the "column" tag columns of the struct, except the omitted and the Set columns
+*/
+func (builder *UpdateBuilder) SetOf(value interface{}) *UpdateBuilder {
+	structs, err := structValues([]interface{}{value})
+	if err == nil && len(structs) != 1 {
+		err = fmt.Errorf("%s UPDATE sets a struct, got %d", MySQL, len(structs))
+	}
+	if err != nil {
+		builder.err = err
+		return builder
+	}
+	builder.value = structs[0]
+	return builder
+}
+
+/*
+  Omit the "column" tag columns of the SetOf struct, eg: the primary key "id"
+*/
+func (builder *UpdateBuilder) Omit(columns ...string) *UpdateBuilder {
+	builder.omit = append(builder.omit, columns...)
+	return builder
+}
+
+/*
+  AND the conditions
+*/
+func (builder *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	builder.addWhere(conds)
+	return builder
+}
+
+/*
+  OR the conditions with the previous conditions: (previous) OR (conds)
+*/
+func (builder *UpdateBuilder) Or(conds ...Cond) *UpdateBuilder {
+	builder.addOr(conds)
+	return builder
+}
+
+/*
+  ORDER BY the columns, eg: "id", "created_time DESC"
+*/
+func (builder *UpdateBuilder) OrderBy(columns ...string) *UpdateBuilder {
+	builder.addOrderBy(columns)
+	return builder
+}
+
+/*
+  ORDER BY the raw sqls, unquoted, never the user input
+*/
+func (builder *UpdateBuilder) OrderByRaw(exprs ...string) *UpdateBuilder {
+	builder.orderBy = append(builder.orderBy, exprs...)
+	return builder
+}
+
+/*
+  LIMIT the rows
+*/
+func (builder *UpdateBuilder) Limit(limit int) *UpdateBuilder {
+	builder.setLimit(limit)
+	return builder
+}
+
+/*
+  Update all the rows, without All, no WHERE is an error, eg: a forgotten Where, Where(And()) or Where(Or())
+*/
+func (builder *UpdateBuilder) All() *UpdateBuilder {
+	builder.all = true
+	return builder
+}
+
+/*
+  Run in the transaction
+*/
+func (builder *UpdateBuilder) Tx(tx *db.Tx) *UpdateBuilder {
+	builder.tx, builder.querier = tx, nil
+	return builder
+}
+
+/*
+  Run by the querier: a DBClient or a Tx, eg: the Tx of WithTx, the nested transactions included
+*/
+func (builder *UpdateBuilder) Querier(querier Querier) *UpdateBuilder {
+	builder.tx, builder.querier = nil, querier
+	return builder
+}
+
+/*
+  Get the sql and the args
+*/
+func (builder *UpdateBuilder) ToSql() (string, []interface{}, error) {
+	if err := builder.check("UPDATE"); err != nil {
+		return "", nil, err
+	}
+	var sets []Cond
+	if builder.value.IsValid() {
+		for _, column := range structColumns(builder.value.Type(), builder.omit) {
+			if containsString(builder.columns, column) {
+				continue
+			}
+			arg, err := structField(builder.value, column)
+			if err != nil {
+				return "", nil, err
+			}
+			set := columnCond(column, " = ?", arg)
+			if set.err != nil {
+				return "", nil, set.err
+			}
+			sets = append(sets, set)
+		}
+	}
+	sets = append(sets, builder.sets...)
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("%s UPDATE has no columns to set", MySQL)
+	}
+	var args []interface{}
+	sql := strings.Builder{}
+	sql.WriteString("UPDATE " + builder.table + " SET ")
+	for i, set := range sets {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		set.build(&sql, &args, false)
+	}
+	builder.buildWhere(&sql, &args)
+	builder.buildOrderBy(&sql, &args)
+	return sql.String(), args, nil
+}
+
+/*
+  Update the rows, return the rows affected, see DBClient.Exec
+*/
+func (builder *UpdateBuilder) Exec() (int64, error) {
+	return builder.ExecContext(context.Background())
+}
+
+/*
+  Update the rows,context
+*/
+func (builder *UpdateBuilder) ExecContext(ctx context.Context) (int64, error) {
+	sql, args, err := builder.ToSql()
+	return builder.exec(ctx, sql, args, err)
+}
+
+/*
+  The DELETE builder
+*/
+type DeleteBuilder struct {
+	builderBase
+}
+
+/*
+  Delete from the table
+*/
+func (client *DBClient) Delete(table string) *DeleteBuilder {
+	builder := &DeleteBuilder{builderBase{client: client}}
+	builder.setTable(table)
+	return builder
+}
+
+/*
+  AND the conditions
+*/
+func (builder *DeleteBuilder) Where(conds ...Cond) *DeleteBuilder {
+	builder.addWhere(conds)
+	return builder
+}
+
+/*
+  OR the conditions with the previous conditions: (previous) OR (conds)
+*/
+func (builder *DeleteBuilder) Or(conds ...Cond) *DeleteBuilder {
+	builder.addOr(conds)
+	return builder
+}
+
+/*
+  ORDER BY the columns, eg: "id", "created_time DESC"
+*/
+func (builder *DeleteBuilder) OrderBy(columns ...string) *DeleteBuilder {
+	builder.addOrderBy(columns)
+	return builder
+}
+
+/*
+  ORDER BY the raw sqls, unquoted, never the user input
+*/
+func (builder *DeleteBuilder) OrderByRaw(exprs ...string) *DeleteBuilder {
+	builder.orderBy = append(builder.orderBy, exprs...)
+	return builder
+}
+
+/*
+  LIMIT the rows
+*/
+func (builder *DeleteBuilder) Limit(limit int) *DeleteBuilder {
+	builder.setLimit(limit)
+	return builder
+}
+
+/*
+  Delete all the rows, without All, no WHERE is an error, eg: a forgotten Where, Where(And()) or Where(Or())
+*/
+func (builder *DeleteBuilder) All() *DeleteBuilder {
+	builder.all = true
+	return builder
+}
+
+/*
+  Run in the transaction
+*/
+func (builder *DeleteBuilder) Tx(tx *db.Tx) *DeleteBuilder {
+	builder.tx, builder.querier = tx, nil
+	return builder
+}
+
+/*
+  Run by the querier: a DBClient or a Tx, eg: the Tx of WithTx, the nested transactions included
+*/
+func (builder *DeleteBuilder) Querier(querier Querier) *DeleteBuilder {
+	builder.tx, builder.querier = nil, querier
+	return builder
+}
+
+/*
+  Get the sql and the args
+*/
+func (builder *DeleteBuilder) ToSql() (string, []interface{}, error) {
+	if err := builder.check("DELETE"); err != nil {
+		return "", nil, err
+	}
+	var args []interface{}
+	sql := strings.Builder{}
+	sql.WriteString("DELETE FROM " + builder.table)
+	builder.buildWhere(&sql, &args)
+	builder.buildOrderBy(&sql, &args)
+	return sql.String(), args, nil
+}
+
+/*
+  Delete the rows, return the rows affected, see DBClient.Exec
+*/
+func (builder *DeleteBuilder) Exec() (int64, error) {
+	return builder.ExecContext(context.Background())
+}
+
+/*
+  Delete the rows,context
+*/
+func (builder *DeleteBuilder) ExecContext(ctx context.Context) (int64, error) {
+	sql, args, err := builder.ToSql()
+	return builder.exec(ctx, sql, args, err)
+}
@@ -0,0 +1,152 @@
+package tsgmysqlutils
+
+/*
+ Query builder test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	client, fake := newFakeClient(t, map[string]fakeResult{
+		"SELECT `id`, `name` FROM `we_test_tab1` WHERE `gender` = ? AND (`name` LIKE ? OR `id` > ?) ORDER BY `id` DESC LIMIT ? OFFSET ?": {
+			columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "tony"}}},
+		"SELECT `id` FROM `we_test_tab1` WHERE `id` = ?": {columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}},
+	})
+	type tab struct {
+		Id      int64  `column:"id"`
+		Name    string `column:"name"`
+		Gender  int64  `column:"gender"`
+		Ignored []tab
+	}
+	cases := []struct {
+		builder interface {
+			ToSql() (string, []interface{}, error)
+		}
+		expected string
+	}{
+		{client.Select("id", "name").From("we_test_tab1").Where(Eq("gender", 2), Or(Like("name", "t%"), Gt("id", 10))).
+			OrderBy("id DESC").Limit(10).Offset(20),
+			"SELECT `id`, `name` FROM `we_test_tab1` WHERE `gender` = ? AND (`name` LIKE ? OR `id` > ?) ORDER BY `id` DESC LIMIT ? OFFSET ? [2 t% 10 10 20]"},
+		{client.Select("t1.*", "t2.note AS remark").Distinct().From("we_test_tab1 t1").LeftJoin("we_test_tab2 t2", "t2.tab1_id = t1.id AND t2.kind = ?", 3).
+			Where(In("t1.id", []int64{1, 2}), Expr("t1.age > ? OR t1.vip = 1", 18), IsNull("t2.deleted_time")).ForUpdate(),
+			"SELECT DISTINCT `t1`.*, `t2`.`note` AS `remark` FROM `we_test_tab1` `t1` LEFT JOIN `we_test_tab2` `t2` ON t2.tab1_id = t1.id AND t2.kind = ? " +
+				"WHERE `t1`.`id` IN (?, ?) AND (t1.age > ? OR t1.vip = 1) AND `t2`.`deleted_time` IS NULL FOR UPDATE [3 1 2 18]"},
+		{client.Select("gender").ColumnsRaw("COUNT(*) AS total").From("we_test_tab1").Where(Eq("is_deleted", 0)).Or(Eq("name", nil), In("id", []int{})).
+			GroupBy("gender").Having(Expr("COUNT(*) > ?", 10)).OrderBy("gender"),
+			"SELECT `gender`, COUNT(*) AS total FROM `we_test_tab1` WHERE `is_deleted` = ? OR (`name` IS NULL AND 0 = 1) GROUP BY `gender` HAVING COUNT(*) > ? ORDER BY `gender` [0 10]"},
+		{client.Select().ColumnsOf(&tab{}).From("we_test_tab1").Where(And(), Between("id", 1, 9)),
+			"SELECT `id`, `name`, `gender` FROM `we_test_tab1` WHERE `id` BETWEEN ? AND ? [1 9]"},
+		{client.Select("status").From("we_test_tab1").GroupByRaw("DATE(created_time)").OrderByRaw("FIELD(status, 2, 1, 0)").OrderBy("id DESC"),
+			"SELECT `status` FROM `we_test_tab1` GROUP BY DATE(created_time) ORDER BY FIELD(status, 2, 1, 0), `id` DESC []"},
+		{client.Update("we_test_tab1").Set("is_deleted", 1).All(), "UPDATE `we_test_tab1` SET `is_deleted` = ? [1]"},
+		{client.Delete("we_test_tab1").All().Limit(100), "DELETE FROM `we_test_tab1` LIMIT ? [100]"},
+		{client.Insert("we_test_tab1").ValuesOf(tab{Id: 1, Name: "tony", Gender: 1}, []*tab{{Name: "tina", Gender: 2}}).Omit("id"),
+			"INSERT INTO `we_test_tab1` (`name`, `gender`) VALUES (?, ?), (?, ?) [tony 1 tina 2]"},
+		{client.Insert("we_test_tab1").Columns("name", "gender").Values("tony", 1),
+			"INSERT INTO `we_test_tab1` (`name`, `gender`) VALUES (?, ?) [tony 1]"},
+		{client.Update("we_test_tab1").SetOf(tab{Id: 1, Name: "tony", Gender: 1}).Omit("id").SetExpr("gender", "`gender` + ?", 1).
+			Where(Eq("id", 1)),
+			"UPDATE `we_test_tab1` SET `name` = ?, `gender` = `gender` + ? WHERE `id` = ? [tony 1 1]"},
+		{client.Delete("we_test_tab1").Where(Ne("is_deleted", 0), NotIn("id", 7)).OrderBy("id").Limit(100),
+			"DELETE FROM `we_test_tab1` WHERE `is_deleted` <> ? AND `id` NOT IN (?) ORDER BY `id` LIMIT ? [0 7 100]"},
+	}
+	for _, c := range cases {
+		sql, args, err := c.builder.ToSql()
+		if built := fmt.Sprint(sql, " ", args); err != nil || built != c.expected {
+			t.Error("Build sql:", built, err)
+		}
+	}
+
+	failures := []struct {
+		builder interface {
+			ToSql() (string, []interface{}, error)
+		}
+		error string
+	}{
+		{client.Select(), "no table"},
+		{client.Select().From("we_test_tab1").Offset(10), "needs a limit"},
+		{client.Insert("we_test_tab1").Columns("name").Values("tony", 1), "2 values of 1 columns"},
+		{client.Insert("we_test_tab1").ValuesOf(1), "int is not a struct"},
+		{client.Insert("we_test_tab1").Columns("note").ValuesOf(tab{}), "'note' has no field"},
+		{client.Update("we_test_tab1").Where(Eq("id", 1)), "no columns to set"},
+		{client.Select("COUNT(*)").From("we_test_tab1"), `"COUNT(*)" is not an identifier`},
+		{client.Select().From("we_test_tab1").OrderBy("id; DROP TABLE we_test_tab1"), "is not an identifier"},
+		{client.Select().From("we_test_tab1").Where(Or(Eq("id", 1), In("1 = 1 OR id", []int{}))), `"1 = 1 OR id" is not an identifier`},
+		{client.Select().From("we_test_tab1").GroupBy("gender").Having(Gt("COUNT(*)", 1)), "is not an identifier"},
+		{client.Select().From("we_test_tab1 WHERE 1 = 1"), "is not an identifier"},
+		{client.Update("we_test_tab1").SetExpr("name = 'x', gender", "?", 1).Where(Eq("id", 1)), "is not an identifier"},
+		{client.Insert("we_test_tab1").Columns("name) VALUES (1); --").Values(1), "is not an identifier"},
+		{client.Update("we_test_tab1").Set("name", "tony"), "UPDATE has no WHERE"},
+		{client.Delete("we_test_tab1").Where(And(), Or()), "DELETE has no WHERE"},
+	}
+	for _, f := range failures {
+		if _, _, err := f.builder.ToSql(); err == nil || !strings.Contains(err.Error(), f.error) {
+			t.Error("Build sql failure:", f.error, err)
+		}
+	}
+
+	// the builders run by the client hooks
+	var sqls []string
+	client.AddHook(HookFuncs{After: func(ctx context.Context, event *QueryEvent) {
+		sqls = append(sqls, event.Sql)
+	}})
+	tabs, err := SelectAll[tab](client.Select("id", "name").From("we_test_tab1").Where(Eq("gender", 2), Or(Like("name", "t%"), Gt("id", 10))).
+		OrderBy("id DESC").Limit(10).Offset(20))
+	if err != nil || len(tabs) != 1 || tabs[0].Name != "tony" {
+		t.Error("Select all:", tabs, err)
+	}
+	tx, _ := client.TxBegin()
+	affected, err := client.Delete("we_test_tab1").Where(Eq("id", 1)).Tx(tx).Exec()
+	client.TxCommit(tx)
+	log := fake.getLog()
+	if err != nil || affected != 1 || len(sqls) != 2 || log[len(log)-2] != "exec DELETE FROM `we_test_tab1` WHERE `id` = ? [1]" {
+		t.Error("Delete tx:", affected, sqls, log, err)
+	}
+	if _, err = client.Update("we_test_tab1").Exec(); err == nil || len(sqls) != 2 {
+		t.Error("Update no sets:", sqls, err)
+	}
+	// the Tx of WithTx is a querier of the builders
+	fake.log = nil
+	var done *Tx
+	err = client.WithTx(context.Background(), nil, func(tx *Tx) error {
+		done = tx
+		if _, err := client.Update("we_test_tab1").Set("name", "tony").Where(Eq("id", 1)).Querier(tx).Exec(); err != nil {
+			return err
+		}
+		_, err := client.Select("id").From("we_test_tab1").Where(Eq("id", 1)).Querier(tx).QueryAggregate()
+		return err
+	})
+	expected := fmt.Sprint([]string{"begin isolation=0 read_only=false", "exec UPDATE `we_test_tab1` SET `name` = ? WHERE `id` = ? [tony 1]",
+		"query SELECT `id` FROM `we_test_tab1` WHERE `id` = ? [1]", "commit"})
+	if log := fmt.Sprint(fake.getLog()); err != nil || log != expected {
+		t.Error("Querier tx:", log, err)
+	}
+	if _, err = client.Delete("we_test_tab1").Where(Eq("id", 1)).Querier(done).Exec(); err != db.ErrTxDone {
+		t.Error("Querier done tx:", err)
+	}
+
+	// the built args are not bound again, a slice arg is passed to the driver unchanged
+	sqls = nil
+	var args []interface{}
+	client.AddHook(HookFuncs{After: func(ctx context.Context, event *QueryEvent) {
+		args = event.Args
+	}})
+	client.Update("we_test_tab1").Set("tags", "a").Where(Eq("tags", []string{"b", "c"})).Exec()
+	if len(sqls) != 1 || sqls[0] != "UPDATE `we_test_tab1` SET `tags` = ? WHERE `tags` = ?" || len(args) != 2 {
+		t.Error("Built args bound again:", sqls, args)
+	}
+	if _, err = client.Exec("UPDATE `we_test_tab1` SET `tags` = ? WHERE `tags` IN (?)", "a", []string{"b", "c"}); err != nil ||
+		sqls[1] != "UPDATE `we_test_tab1` SET `tags` = ? WHERE `tags` IN (?, ?)" {
+		t.Error("Sql args bound:", sqls, err)
+	}
+}
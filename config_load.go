@@ -0,0 +1,458 @@
+package tsgmysqlutils
+
+/*
+ MySQL connect configuration loaders: environment variables and JSON/YAML/TOML files
+  Usage:
+	// APP_DB_HOST, APP_DB_PORT, APP_DB_USER, APP_DB_PASS, APP_DB_NAME ...
+	config, err := tsgmysqlutils.LoadDBConfigFromEnv("APP")
+
+	// db.yaml:
+	//   charset: utf8mb4
+	//   databases:
+	//     main:
+	//       host: 127.0.0.1
+	//       user: root
+	//       name: test
+	//     report:
+	//       dsn: mysql://report@10.0.0.2:3306/report
+	configs, err := tsgmysqlutils.LoadDBConfigsFromFile("db.yaml")
+
+	// the file "main" database, overridden by the APP_DB_* environment variables
+	config, err := tsgmysqlutils.LoadDBConfig("db.yaml", "main", "APP")
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  The database name of a configuration file without "databases"
+*/
+const DefaultDBConfigName = "default"
+
+/*
+  A database entry of the configuration file, the top-level entry is the default of all "databases"
+*/
+type dbConfigEntry struct {
+	// a URL or DSN, the other fields take precedence
+	DSN                string            `json:"dsn" yaml:"dsn" toml:"dsn"`
+	Host               string            `json:"host" yaml:"host" toml:"host"`
+	Port               int               `json:"port" yaml:"port" toml:"port"`
+	User               string            `json:"user" yaml:"user" toml:"user"`
+	Pass               string            `json:"pass" yaml:"pass" toml:"pass"`
+	Name               string            `json:"name" yaml:"name" toml:"name"`
+	Protocol           string            `json:"protocol" yaml:"protocol" toml:"protocol"`
+	Socket             string            `json:"socket" yaml:"socket" toml:"socket"`
+	Charset            string            `json:"charset" yaml:"charset" toml:"charset"`
+	Collation          string            `json:"collation" yaml:"collation" toml:"collation"`
+	LocalTime          *bool             `json:"local_time" yaml:"local_time" toml:"local_time"`
+	ConnTimeout        string            `json:"conn_timeout" yaml:"conn_timeout" toml:"conn_timeout"`
+	ReadTimeout        string            `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout       string            `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	Params             map[string]string `json:"params" yaml:"params" toml:"params"`
+	PingTimeout        string            `json:"ping_timeout" yaml:"ping_timeout" toml:"ping_timeout"`
+	PingRetries        int               `json:"ping_retries" yaml:"ping_retries" toml:"ping_retries"`
+	PingBackoff        string            `json:"ping_backoff" yaml:"ping_backoff" toml:"ping_backoff"`
+	SlowSqlThreshold   string            `json:"slow_sql_threshold" yaml:"slow_sql_threshold" toml:"slow_sql_threshold"`
+	SlowConnThreshold  string            `json:"slow_conn_threshold" yaml:"slow_conn_threshold" toml:"slow_conn_threshold"`
+	SlowSqlSampleRate  float64           `json:"slow_sql_sample_rate" yaml:"slow_sql_sample_rate" toml:"slow_sql_sample_rate"`
+	SlowSqlLogInterval string            `json:"slow_sql_log_interval" yaml:"slow_sql_log_interval" toml:"slow_sql_log_interval"`
+	// connection pool
+	MaxOpenConns    int    `json:"max_open_conns" yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int    `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime string `json:"conn_max_lifetime" yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime string `json:"conn_max_idle_time" yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	// prepared statements
+	StmtCacheSize int   `json:"stmt_cache_size" yaml:"stmt_cache_size" toml:"stmt_cache_size"`
+	SkipPrepare   *bool `json:"skip_prepare" yaml:"skip_prepare" toml:"skip_prepare"`
+}
+
+type dbConfigFile struct {
+	dbConfigEntry `yaml:",inline"`
+	Databases     map[string]dbConfigEntry `json:"databases" yaml:"databases" toml:"databases"`
+}
+
+/*
+  Load a configuration from the prefixed environment variables, eg: prefix "APP":
+	APP_DB_DSN (a URL or DSN, the other variables take precedence),
+	APP_DB_HOST, APP_DB_PORT, APP_DB_USER, APP_DB_PASS, APP_DB_NAME,
+	APP_DB_PROTOCOL, APP_DB_SOCKET, APP_DB_CHARSET, APP_DB_COLLATION, APP_DB_LOCAL_TIME,
+	APP_DB_CONN_TIMEOUT, APP_DB_READ_TIMEOUT, APP_DB_WRITE_TIMEOUT,
+	APP_DB_PARAMS (eg: tls=skip-verify&time_zone=%27%2B08%3A00%27),
+	APP_DB_PING_TIMEOUT, APP_DB_PING_RETRIES, APP_DB_PING_BACKOFF,
+	APP_DB_SLOW_SQL_THRESHOLD, APP_DB_SLOW_CONN_THRESHOLD, APP_DB_SLOW_SQL_SAMPLE_RATE, APP_DB_SLOW_SQL_LOG_INTERVAL,
+	APP_DB_MAX_OPEN_CONNS, APP_DB_MAX_IDLE_CONNS, APP_DB_CONN_MAX_LIFETIME, APP_DB_CONN_MAX_IDLE_TIME,
+	APP_DB_STMT_CACHE_SIZE, APP_DB_SKIP_PREPARE
+  If the prefix is empty, the variables are DB_HOST, DB_PORT etc.
+*/
+func LoadDBConfigFromEnv(prefix string) (DBConfig, error) {
+	var config DBConfig
+	err := applyEnvDBConfig(&config, prefix)
+	if err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+/*
+  Load all configurations of a JSON, YAML or TOML file (by the file extension), keyed by the database name
+*/
+func LoadDBConfigsFromFile(path string) (map[string]DBConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file dbConfigFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(content), &file)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown key '%s'", meta.Undecoded()[0])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported db config file '%s', expect .json, .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid db config file '%s': %v", path, err)
+	}
+	configs := make(map[string]DBConfig)
+	if len(file.Databases) == 0 {
+		var config DBConfig
+		err = file.dbConfigEntry.applyTo(&config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid db config file '%s': %v", path, err)
+		}
+		configs[DefaultDBConfigName] = config
+		return configs, nil
+	}
+	for name, entry := range file.Databases {
+		var config DBConfig
+		err = file.dbConfigEntry.applyTo(&config)
+		if err == nil {
+			err = entry.applyTo(&config)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid db config file '%s', database '%s': %v", path, name, err)
+		}
+		configs[name] = config
+	}
+	return configs, nil
+}
+
+/*
+  Load a named configuration of a JSON, YAML or TOML file, if the name is empty, DefaultDBConfigName
+*/
+func LoadDBConfigFromFile(path, name string) (DBConfig, error) {
+	configs, err := LoadDBConfigsFromFile(path)
+	if err != nil {
+		return DBConfig{}, err
+	}
+	return getNamedDBConfig(configs, path, name)
+}
+
+/*
+  Load a configuration with the precedence: environment variables > file > defaults,
+  the file is skipped if the path is empty, see LoadDBConfigFromFile and LoadDBConfigFromEnv
+*/
+func LoadDBConfig(path, name, envPrefix string) (DBConfig, error) {
+	var config DBConfig
+	if path != "" {
+		configs, err := LoadDBConfigsFromFile(path)
+		if err != nil {
+			return config, err
+		}
+		config, err = getNamedDBConfig(configs, path, name)
+		if err != nil {
+			return config, err
+		}
+	}
+	err := applyEnvDBConfig(&config, envPrefix)
+	if err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+func getNamedDBConfig(configs map[string]DBConfig, path, name string) (DBConfig, error) {
+	if name == "" {
+		name = DefaultDBConfigName
+	}
+	config, ok := configs[name]
+	if !ok {
+		return config, fmt.Errorf("database '%s' not found in db config file '%s'", name, path)
+	}
+	return config, config.Validate()
+}
+
+func applyEnvDBConfig(config *DBConfig, prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	prefix += "DB_"
+	var entry dbConfigEntry
+	entry.DSN = os.Getenv(prefix + "DSN")
+	entry.Host = os.Getenv(prefix + "HOST")
+	entry.User = os.Getenv(prefix + "USER")
+	entry.Pass = os.Getenv(prefix + "PASS")
+	entry.Name = os.Getenv(prefix + "NAME")
+	entry.Protocol = os.Getenv(prefix + "PROTOCOL")
+	entry.Socket = os.Getenv(prefix + "SOCKET")
+	entry.Charset = os.Getenv(prefix + "CHARSET")
+	entry.Collation = os.Getenv(prefix + "COLLATION")
+	entry.ConnTimeout = os.Getenv(prefix + "CONN_TIMEOUT")
+	entry.ReadTimeout = os.Getenv(prefix + "READ_TIMEOUT")
+	entry.WriteTimeout = os.Getenv(prefix + "WRITE_TIMEOUT")
+	entry.PingTimeout = os.Getenv(prefix + "PING_TIMEOUT")
+	entry.PingBackoff = os.Getenv(prefix + "PING_BACKOFF")
+	entry.SlowSqlThreshold = os.Getenv(prefix + "SLOW_SQL_THRESHOLD")
+	entry.SlowConnThreshold = os.Getenv(prefix + "SLOW_CONN_THRESHOLD")
+	entry.SlowSqlLogInterval = os.Getenv(prefix + "SLOW_SQL_LOG_INTERVAL")
+	if rate := os.Getenv(prefix + "SLOW_SQL_SAMPLE_RATE"); rate != "" {
+		sampleRate, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return fmt.Errorf("invalid env %sSLOW_SQL_SAMPLE_RATE '%s'", prefix, rate)
+		}
+		entry.SlowSqlSampleRate = sampleRate
+	}
+	entry.ConnMaxLifetime = os.Getenv(prefix + "CONN_MAX_LIFETIME")
+	entry.ConnMaxIdleTime = os.Getenv(prefix + "CONN_MAX_IDLE_TIME")
+	numbers := []struct {
+		name  string
+		value *int
+	}{
+		{"PING_RETRIES", &entry.PingRetries}, {"MAX_OPEN_CONNS", &entry.MaxOpenConns}, {"MAX_IDLE_CONNS", &entry.MaxIdleConns},
+		{"STMT_CACHE_SIZE", &entry.StmtCacheSize},
+	}
+	for _, number := range numbers {
+		if value := os.Getenv(prefix + number.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid env %s%s '%s'", prefix, number.name, value)
+			}
+			*number.value = n
+		}
+	}
+	if port := os.Getenv(prefix + "PORT"); port != "" {
+		p, err := parsePort(port)
+		if err != nil {
+			return fmt.Errorf("invalid env %sPORT: %v", prefix, err)
+		}
+		entry.Port = p
+	}
+	if localTime := os.Getenv(prefix + "LOCAL_TIME"); localTime != "" {
+		isLocalTime, err := strconv.ParseBool(localTime)
+		if err != nil {
+			return fmt.Errorf("invalid env %sLOCAL_TIME '%s'", prefix, localTime)
+		}
+		entry.LocalTime = &isLocalTime
+	}
+	if skipPrepare := os.Getenv(prefix + "SKIP_PREPARE"); skipPrepare != "" {
+		isSkipPrepare, err := strconv.ParseBool(skipPrepare)
+		if err != nil {
+			return fmt.Errorf("invalid env %sSKIP_PREPARE '%s'", prefix, skipPrepare)
+		}
+		entry.SkipPrepare = &isSkipPrepare
+	}
+	if params := os.Getenv(prefix + "PARAMS"); params != "" {
+		values, err := url.ParseQuery(params)
+		if err != nil {
+			return fmt.Errorf("invalid env %sPARAMS: %v", prefix, err)
+		}
+		entry.Params = make(map[string]string)
+		for key := range values {
+			entry.Params[key] = values.Get(key)
+		}
+	}
+	return entry.applyTo(config)
+}
+
+/*
+  Override the configuration by the non-empty fields of the entry
+*/
+func (entry dbConfigEntry) applyTo(config *DBConfig) error {
+	if entry.DSN != "" {
+		parsed, err := ParseDBConfig(entry.DSN)
+		if err != nil {
+			return err
+		}
+		entry.mergeDSN(parsed)
+	}
+	if entry.Host != "" {
+		config.DbHost = entry.Host
+	}
+	if entry.Port != 0 {
+		config.DbPort = entry.Port
+	}
+	if entry.User != "" {
+		config.DbUser = entry.User
+	}
+	if entry.Pass != "" {
+		config.DbPass = entry.Pass
+	}
+	if entry.Name != "" {
+		config.DbName = entry.Name
+	}
+	if entry.Protocol != "" {
+		config.DbProtocol = entry.Protocol
+	}
+	if entry.Socket != "" {
+		config.DbSocket = entry.Socket
+	}
+	if entry.Charset != "" {
+		config.DbCharset = entry.Charset
+	}
+	if entry.Collation != "" {
+		config.DbCollation = entry.Collation
+	}
+	if entry.LocalTime != nil {
+		config.IsLocalTime = *entry.LocalTime
+	}
+	var err error
+	if entry.ConnTimeout != "" {
+		if config.ConnTimeout, err = parseTimeout("conn_timeout", entry.ConnTimeout); err != nil {
+			return err
+		}
+	}
+	if entry.ReadTimeout != "" {
+		if config.ReadTimeout, err = parseTimeout("read_timeout", entry.ReadTimeout); err != nil {
+			return err
+		}
+	}
+	if entry.WriteTimeout != "" {
+		if config.WriteTimeout, err = parseTimeout("write_timeout", entry.WriteTimeout); err != nil {
+			return err
+		}
+	}
+	if entry.PingTimeout != "" {
+		if config.PingTimeout, err = parseTimeout("ping_timeout", entry.PingTimeout); err != nil {
+			return err
+		}
+	}
+	if entry.PingRetries != 0 {
+		config.PingRetries = entry.PingRetries
+	}
+	if entry.PingBackoff != "" {
+		if config.PingBackoff, err = parseTimeout("ping_backoff", entry.PingBackoff); err != nil {
+			return err
+		}
+	}
+	durations := []struct {
+		key   string
+		value string
+		field *time.Duration
+	}{
+		{"slow_sql_threshold", entry.SlowSqlThreshold, &config.SlowSqlThreshold},
+		{"slow_conn_threshold", entry.SlowConnThreshold, &config.SlowConnThreshold},
+		{"slow_sql_log_interval", entry.SlowSqlLogInterval, &config.SlowSqlLogInterval},
+	}
+	for _, duration := range durations {
+		if duration.value != "" {
+			if *duration.field, err = parseTimeout(duration.key, duration.value); err != nil {
+				return err
+			}
+		}
+	}
+	if entry.SlowSqlSampleRate != 0 {
+		config.SlowSqlSampleRate = entry.SlowSqlSampleRate
+	}
+	if entry.MaxOpenConns != 0 {
+		config.MaxOpenConns = entry.MaxOpenConns
+	}
+	if entry.MaxIdleConns != 0 {
+		config.MaxIdleConns = entry.MaxIdleConns
+	}
+	if entry.ConnMaxLifetime != "" {
+		if config.ConnMaxLifetime, err = parseTimeout("conn_max_lifetime", entry.ConnMaxLifetime); err != nil {
+			return err
+		}
+	}
+	if entry.ConnMaxIdleTime != "" {
+		if config.ConnMaxIdleTime, err = parseTimeout("conn_max_idle_time", entry.ConnMaxIdleTime); err != nil {
+			return err
+		}
+	}
+	if entry.StmtCacheSize != 0 {
+		config.StmtCacheSize = entry.StmtCacheSize
+	}
+	if entry.SkipPrepare != nil {
+		config.SkipPrepare = *entry.SkipPrepare
+	}
+	if len(entry.Params) > 0 {
+		params := make(map[string]string, len(config.Params)+len(entry.Params))
+		for key, value := range config.Params {
+			params[key] = value
+		}
+		for key, value := range entry.Params {
+			params[key] = value
+		}
+		config.Params = params
+	}
+	return nil
+}
+
+/*
+  Fill the empty fields of the entry by the parsed DSN
+*/
+func (entry *dbConfigEntry) mergeDSN(config DBConfig) {
+	fields := []struct {
+		value  *string
+		parsed string
+	}{
+		{&entry.Host, config.DbHost}, {&entry.User, config.DbUser}, {&entry.Pass, config.DbPass},
+		{&entry.Name, config.DbName}, {&entry.Protocol, config.DbProtocol}, {&entry.Socket, config.DbSocket},
+		{&entry.Charset, config.DbCharset}, {&entry.Collation, config.DbCollation},
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			*field.value = field.parsed
+		}
+	}
+	if entry.Port == 0 {
+		entry.Port = config.DbPort
+	}
+	if entry.LocalTime == nil && config.IsLocalTime {
+		entry.LocalTime = &config.IsLocalTime
+	}
+	timeouts := []struct {
+		value  *string
+		parsed time.Duration
+	}{
+		{&entry.ConnTimeout, config.ConnTimeout}, {&entry.ReadTimeout, config.ReadTimeout}, {&entry.WriteTimeout, config.WriteTimeout},
+	}
+	for _, timeout := range timeouts {
+		if *timeout.value == "" && timeout.parsed > 0 {
+			*timeout.value = timeout.parsed.String()
+		}
+	}
+	params := make(map[string]string, len(config.Params)+len(entry.Params))
+	for key, value := range config.Params {
+		params[key] = value
+	}
+	for key, value := range entry.Params {
+		params[key] = value
+	}
+	entry.Params = params
+}
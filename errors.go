@@ -0,0 +1,203 @@
+package tsgmysqlutils
+
+/*
+ Typed MySQL errors
+  Usage:
+	_, err := client.Exec(sql, args...)
+	if tsgmysqlutils.IsDuplicateKey(err) {
+		// conflict
+	} else if tsgmysqlutils.IsRetryable(err) {
+		// retry the transaction
+	}
+
+	var sqlErr *tsgmysqlutils.SqlError
+	if errors.As(err, &sqlErr) {
+		log.Println(sqlErr.Number(), sqlErr.Sql)
+	}
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	db "database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+)
+
+/*
+  The error classes, match them by errors.Is or the Is* functions
+*/
+var (
+	ErrDuplicateKey        = errors.New(MySQL + " duplicate key")
+	ErrDeadlock            = errors.New(MySQL + " deadlock")
+	ErrLockWaitTimeout     = errors.New(MySQL + " lock wait timeout")
+	ErrForeignKeyViolation = errors.New(MySQL + " foreign key violation")
+	ErrConnectionLost      = errors.New(MySQL + " connection lost")
+	ErrReadOnly            = errors.New(MySQL + " read only")
+)
+
+/*
+  ER_OPTION_PREVENTS_STATEMENT: the MySQL server is running with the --option so it cannot execute this statement
+*/
+const erOptionPreventsStatement = 1290
+
+/*
+  The MySQL error numbers of the error classes
+*/
+var errorClasses = map[uint16]error{
+	// ER_DUP_KEY, ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+	1022: ErrDuplicateKey,
+	1062: ErrDuplicateKey,
+	1586: ErrDuplicateKey,
+	// ER_LOCK_DEADLOCK
+	1213: ErrDeadlock,
+	// ER_LOCK_WAIT_TIMEOUT
+	1205: ErrLockWaitTimeout,
+	// ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED, ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+	1216: ErrForeignKeyViolation,
+	1217: ErrForeignKeyViolation,
+	1451: ErrForeignKeyViolation,
+	1452: ErrForeignKeyViolation,
+	// ER_SERVER_SHUTDOWN, ER_CONNECTION_KILLED, CR_SERVER_GONE_ERROR, CR_SERVER_LOST, ER_CLIENT_INTERACTION_TIMEOUT
+	1053: ErrConnectionLost,
+	1927: ErrConnectionLost,
+	2006: ErrConnectionLost,
+	2013: ErrConnectionLost,
+	4031: ErrConnectionLost,
+	// ER_OPTION_PREVENTS_STATEMENT of --read-only and --super-read-only only, see errorClass,
+	// ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION, ER_READ_ONLY_MODE
+	1290: ErrReadOnly,
+	1792: ErrReadOnly,
+	1836: ErrReadOnly,
+}
+
+/*
+  The error of a statement, carry the sql and the driver error
+*/
+type SqlError struct {
+	Op  string
+	Sql string
+	Err error
+}
+
+/*
+  The driver error message, unchanged for the callers matching the message
+*/
+func (e *SqlError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SqlError) Unwrap() error {
+	return e.Err
+}
+
+/*
+  Match the error classes, eg: errors.Is(err, ErrDeadlock)
+*/
+func (e *SqlError) Is(target error) bool {
+	return target != nil && errorClass(e.Err) == target
+}
+
+/*
+  The MySQL error number, 0 if not a MySQL server error
+*/
+func (e *SqlError) Number() uint16 {
+	number, _ := MySQLErrorNumber(e.Err)
+	return number
+}
+
+/*
+  Wrap the error of a statement by SqlError, the nil, sql.ErrNoRows and SqlError errors are unchanged
+*/
+func wrapSqlError(op, sql string, err error) error {
+	var sqlErr *SqlError
+	if err == nil || err == db.ErrNoRows || errors.As(err, &sqlErr) {
+		return err
+	}
+	return &SqlError{Op: op, Sql: sql, Err: err}
+}
+
+/*
+  Get the MySQL error number of the go-sql-driver MySQLError in the error chain
+*/
+func MySQLErrorNumber(err error) (uint16, bool) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number, true
+	}
+	return 0, false
+}
+
+/*
+  Get the sql of the SqlError in the error chain, "" if none
+*/
+func ErrorSql(err error) string {
+	var sqlErr *SqlError
+	if errors.As(err, &sqlErr) {
+		return sqlErr.Sql
+	}
+	return ""
+}
+
+/*
+  Get the error class of the error, nil if unknown
+*/
+func errorClass(err error) error {
+	if err == nil {
+		return nil
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// the other options, eg: --secure-file-priv, --skip-grant-tables
+		if mysqlErr.Number == erOptionPreventsStatement && !strings.Contains(mysqlErr.Message, "read-only") {
+			return nil
+		}
+		return errorClasses[mysqlErr.Number]
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, db.ErrConnDone) {
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+func IsDuplicateKey(err error) bool {
+	return errorClass(err) == ErrDuplicateKey
+}
+
+func IsDeadlock(err error) bool {
+	return errorClass(err) == ErrDeadlock
+}
+
+func IsLockWaitTimeout(err error) bool {
+	return errorClass(err) == ErrLockWaitTimeout
+}
+
+func IsForeignKeyViolation(err error) bool {
+	return errorClass(err) == ErrForeignKeyViolation
+}
+
+/*
+  The connection is broken: the server gone away, lost or killed connections, the bad connections of the driver
+*/
+func IsConnectionLost(err error) bool {
+	return errorClass(err) == ErrConnectionLost
+}
+
+/*
+  The server or the transaction is read only, eg: a demoted primary
+*/
+func IsReadOnly(err error) bool {
+	return errorClass(err) == ErrReadOnly
+}
+
+/*
+  The transaction may succeed if retried: deadlock or lock wait timeout
+*/
+func IsRetryable(err error) bool {
+	class := errorClass(err)
+	return class == ErrDeadlock || class == ErrLockWaitTimeout
+}
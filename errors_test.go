@@ -0,0 +1,85 @@
+package tsgmysqlutils
+
+/*
+ MySQL errors test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"testing"
+)
+
+func TestSqlError(t *testing.T) {
+	sql := "INSERT INTO user (id) VALUES (?)"
+	classes := []struct {
+		err   error
+		class error
+		is    func(err error) bool
+	}{
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, ErrDuplicateKey, IsDuplicateKey},
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, ErrDeadlock, IsDeadlock},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, ErrLockWaitTimeout, IsLockWaitTimeout},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, ErrForeignKeyViolation, IsForeignKeyViolation},
+		{mysql.ErrInvalidConn, ErrConnectionLost, IsConnectionLost},
+		{&mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --read-only option"}, ErrReadOnly, IsReadOnly},
+	}
+	for _, c := range classes {
+		err := wrapSqlError(OpExec, sql, c.err)
+		if !c.is(err) || !c.is(c.err) || !errors.Is(err, c.class) || !errors.Is(err, c.err) || err.Error() != c.err.Error() {
+			t.Error("Error class:", c.class, err)
+		}
+		if ErrorSql(err) != sql || ErrorSql(fmt.Errorf("wrapped: %w", err)) != sql {
+			t.Error("Error sql:", err)
+		}
+		for _, other := range classes {
+			if other.class != c.class && (other.is(err) || errors.Is(err, other.class)) {
+				t.Error("Error class:", c.class, "is", other.class)
+			}
+		}
+	}
+	superReadOnly := &mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --super-read-only option"}
+	secureFilePriv := &mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --secure-file-priv option"}
+	if !IsReadOnly(superReadOnly) || IsReadOnly(secureFilePriv) || errors.Is(wrapSqlError(OpExec, sql, secureFilePriv), ErrReadOnly) {
+		t.Error("Error 1290 class")
+	}
+	if !IsRetryable(wrapSqlError(OpExec, sql, classes[1].err)) || IsRetryable(wrapSqlError(OpExec, sql, classes[0].err)) || IsRetryable(context.Canceled) {
+		t.Error("Retryable errors")
+	}
+	var sqlErr *SqlError
+	if !errors.As(wrapSqlError(OpExec, sql, classes[0].err), &sqlErr) || sqlErr.Number() != 1062 || sqlErr.Op != OpExec {
+		t.Error("Sql error:", sqlErr)
+	}
+	if wrapSqlError(OpQueryRow, sql, db.ErrNoRows) != db.ErrNoRows || wrapSqlError(OpExec, sql, nil) != nil || IsDuplicateKey(nil) {
+		t.Error("Unwrapped errors")
+	}
+
+	client := newTestClient()
+	event := newQueryEvent(OpExec, nil, sql, []interface{}{1})
+	err := client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		return classes[0].err
+	})
+	if !IsDuplicateKey(err) || ErrorSql(err) != sql || ErrorSql(event.Err) != sql {
+		t.Error("Query error:", err)
+	}
+
+	// the transaction statements are wrapped too, the tx goes on
+	fakeClient, _ := newFakeClient(t, map[string]fakeResult{sql: {err: classes[0].err}})
+	tx, err := fakeClient.TxBegin()
+	if err != nil {
+		t.Fatal("Tx begin:", err)
+	}
+	defer fakeClient.TxRollback(tx)
+	if _, err = fakeClient.TxExec(tx, sql, 1); !IsDuplicateKey(err) || IsDeadlock(err) || ErrorSql(err) != sql {
+		t.Error("Tx exec error:", err)
+	}
+	if _, err = fakeClient.TxExec(tx, "UPDATE user SET age = 18"); err != nil {
+		t.Error("Tx exec after error:", err)
+	}
+}
@@ -0,0 +1,255 @@
+package tsgmysqlutils
+
+/*
+ Generic typed queries, the columns are mapped to the struct fields by the "column" tags, see structPlan
+  Usage:
+	tab1, err := tsgmysqlutils.QueryOne[WeTestTab1](client, "SELECT * FROM we_test_tab1 WHERE id = ?", 1)
+	tab1s, err := tsgmysqlutils.QueryAll[WeTestTab1](client, "SELECT * FROM we_test_tab1 WHERE gender = ?", 2)
+	names, err := tsgmysqlutils.QueryAll[string](client, "SELECT name FROM we_test_tab1")
+
+	// the first column is the key, the struct values get it too if they have the field
+	byId, err := tsgmysqlutils.QueryMap[int64, WeTestTab1](client, "SELECT id, name, gender FROM we_test_tab1")
+	nameById, err := tsgmysqlutils.QueryMap[int64, string](client, "SELECT id, name FROM we_test_tab1")
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"fmt"
+	"reflect"
+)
+
+/*
+  Get the first row as T: a struct by the "column" tags, or a scalar of a single column, no rows: sql.ErrNoRows,
+  querier: a DBClient or a Tx
+*/
+func QueryOne[T any](querier Querier, sql string, args ...interface{}) (T, error) {
+	return QueryOneContext[T](context.Background(), querier, sql, args...)
+}
+
+/*
+  Get the first row as T,context
+*/
+func QueryOneContext[T any](ctx context.Context, querier Querier, sql string, args ...interface{}) (value T, err error) {
+	client, tx, err := querierClient(querier)
+	if err != nil {
+		return value, err
+	}
+	return queryOne[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get all rows as T: a struct by the "column" tags, or a scalar of a single column, querier: a DBClient or a Tx
+*/
+func QueryAll[T any](querier Querier, sql string, args ...interface{}) ([]T, error) {
+	return QueryAllContext[T](context.Background(), querier, sql, args...)
+}
+
+/*
+  Get all rows as T,context
+*/
+func QueryAllContext[T any](ctx context.Context, querier Querier, sql string, args ...interface{}) ([]T, error) {
+	client, tx, err := querierClient(querier)
+	if err != nil {
+		return nil, err
+	}
+	return queryAll[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get all rows as a map: the first column is the key, the other columns are the value,
+  a struct value gets the key column too if it has the field, the later rows win the duplicate keys,
+  querier: a DBClient or a Tx
+*/
+func QueryMap[K comparable, V any](querier Querier, sql string, args ...interface{}) (map[K]V, error) {
+	return QueryMapContext[K, V](context.Background(), querier, sql, args...)
+}
+
+/*
+  Get all rows as a map,context
+*/
+func QueryMapContext[K comparable, V any](ctx context.Context, querier Querier, sql string, args ...interface{}) (map[K]V, error) {
+	client, tx, err := querierClient(querier)
+	if err != nil {
+		return nil, err
+	}
+	return queryMap[K, V](ctx, client, tx, sql, args)
+}
+
+/*
+  Get the first row as T,transaction
+*/
+func TxQueryOne[T any](client *DBClient, tx *db.Tx, sql string, args ...interface{}) (T, error) {
+	return TxQueryOneContext[T](context.Background(), client, tx, sql, args...)
+}
+
+/*
+  Get the first row as T,transaction,context
+*/
+func TxQueryOneContext[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args ...interface{}) (T, error) {
+	return queryOne[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get all rows as T,transaction
+*/
+func TxQueryAll[T any](client *DBClient, tx *db.Tx, sql string, args ...interface{}) ([]T, error) {
+	return TxQueryAllContext[T](context.Background(), client, tx, sql, args...)
+}
+
+/*
+  Get all rows as T,transaction,context
+*/
+func TxQueryAllContext[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args ...interface{}) ([]T, error) {
+	return queryAll[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get all rows as a map,transaction
+*/
+func TxQueryMap[K comparable, V any](client *DBClient, tx *db.Tx, sql string, args ...interface{}) (map[K]V, error) {
+	return TxQueryMapContext[K, V](context.Background(), client, tx, sql, args...)
+}
+
+/*
+  Get all rows as a map,transaction,context
+*/
+func TxQueryMapContext[K comparable, V any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args ...interface{}) (map[K]V, error) {
+	return queryMap[K, V](ctx, client, tx, sql, args)
+}
+
+/*
+  Query the rows, tx: if nil, not in a transaction
+*/
+func (client *DBClient) queryRows(ctx context.Context, tx *db.Tx, sql string, args []interface{}) (*db.Rows, error) {
+	if tx == nil {
+		return client.QueryListContext(ctx, nil, sql, args...)
+	}
+	return client.TxQueryListContext(ctx, tx, nil, sql, args...)
+}
+
+/*
+  The scan function of the current row into a T value, the prefix targets get the leading columns
+*/
+type scanFunc[T any] func(rows *db.Rows, prefix ...interface{}) (T, error)
+
+/*
+  Get the scan function of T of the columns: a struct by the "column" tags, or a scalar of a single column
+*/
+func newScanFunc[T any](client *DBClient, columns []string) (scanFunc[T], error) {
+	valueType := reflect.TypeOf((*T)(nil)).Elem()
+	if isScalarType(valueType) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("%s %d columns can not be scanned into %s", MySQL, len(columns), valueType)
+		}
+		loc := client.timeLocation()
+		return func(rows *db.Rows, prefix ...interface{}) (T, error) {
+			var value T
+			err := scanScalar(rows, &value, loc, prefix...)
+			return value, err
+		}, nil
+	}
+	scanner, err := client.newStructScanner(valueType, columns)
+	if err != nil {
+		return nil, err
+	}
+	return func(rows *db.Rows, prefix ...interface{}) (T, error) {
+		var value T
+		err := scanner.scan(rows, reflect.ValueOf(&value).Elem(), prefix...)
+		return value, err
+	}, nil
+}
+
+func queryOne[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args []interface{}) (value T, err error) {
+	values, err := queryValues[T](ctx, client, tx, sql, args, 1)
+	if err != nil {
+		return value, err
+	}
+	if len(values) == 0 {
+		return value, db.ErrNoRows
+	}
+	return values[0], nil
+}
+
+func queryAll[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args []interface{}) ([]T, error) {
+	return queryValues[T](ctx, client, tx, sql, args, -1)
+}
+
+/*
+  Query the rows as T, limit: the max rows, if < 0, all rows
+*/
+func queryValues[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args []interface{}, limit int) ([]T, error) {
+	rows, err := client.queryRows(ctx, tx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	scan, err := newScanFunc[T](client, columns)
+	if err != nil {
+		return nil, err
+	}
+	var values []T
+	for (limit < 0 || len(values) < limit) && rows.Next() {
+		value, err := scan(rows)
+		if err != nil {
+			return nil, client.scanError(err, sql, args...)
+		}
+		values = append(values, value)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	return values, nil
+}
+
+func queryMap[K comparable, V any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args []interface{}) (map[K]V, error) {
+	rows, err := client.queryRows(ctx, tx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	if len(columns) < 2 {
+		return nil, fmt.Errorf("%s map needs the key and value columns, got %d columns", MySQL, len(columns))
+	}
+	scan, err := newScanFunc[V](client, columns[1:])
+	if err != nil {
+		return nil, err
+	}
+	// the struct field of the key column
+	var keyIndex []int
+	valueType := reflect.TypeOf((*V)(nil)).Elem()
+	if !isScalarType(valueType) {
+		keyIndex = getStructPlan(valueType).fieldIndex(columns[0])
+		if keyIndex != nil && !reflect.TypeOf((*K)(nil)).Elem().AssignableTo(valueType.FieldByIndex(keyIndex).Type) {
+			keyIndex = nil
+		}
+	}
+	values := make(map[K]V)
+	for rows.Next() {
+		var key K
+		value, err := scan(rows, &key)
+		if err != nil {
+			return nil, client.scanError(err, sql, args...)
+		}
+		if keyIndex != nil {
+			reflect.ValueOf(&value).Elem().FieldByIndex(keyIndex).Set(reflect.ValueOf(key))
+		}
+		values[key] = value
+	}
+	if err = rows.Err(); err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	return values, nil
+}
@@ -0,0 +1,82 @@
+package tsgmysqlutils
+
+/*
+ Generic query test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	db "database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+type genericTab struct {
+	Id      int64     `column:"id"`
+	Name    string    `column:"name"`
+	Created time.Time `column:"created_time"`
+	Note    db.NullString
+	Ignored []genericTab
+}
+
+func TestQueryGeneric(t *testing.T) {
+	client, fake := newFakeClient(t, map[string]fakeResult{
+		"SELECT id, name, created_time, note FROM tab": {
+			columns: []string{"id", "name", "created_time", "note"},
+			rows: [][]driver.Value{
+				{int64(1), []byte("tony"), []byte("2018-04-19 13:20:09"), nil},
+				{int64(2), []byte("tina"), []byte("2018-04-20 08:00:00"), []byte("vip")},
+			},
+		},
+		"SELECT id, name FROM tab": {
+			columns: []string{"id", "name"},
+			rows:    [][]driver.Value{{int64(1), []byte("tony")}, {int64(2), []byte("tina")}},
+		},
+		"SELECT name FROM tab WHERE id = ?": {columns: []string{"name"}, rows: [][]driver.Value{{[]byte("tony")}}},
+		"SELECT name FROM tab WHERE id = 0": {columns: []string{"name"}},
+		"SELECT id, age FROM tab":           {columns: []string{"id", "age"}, rows: [][]driver.Value{{int64(1), int64(18)}}},
+	})
+
+	tabs, err := QueryAll[genericTab](client, "SELECT id, name, created_time, note FROM tab")
+	if err != nil || len(tabs) != 2 {
+		t.Fatal("Query all:", tabs, err)
+	}
+	if tabs[0].Id != 1 || tabs[0].Name != "tony" || !tabs[0].Created.Equal(time.Date(2018, 4, 19, 13, 20, 9, 0, time.UTC)) ||
+		tabs[0].Note.Valid || tabs[1].Note.String != "vip" {
+		t.Error("Query all values:", tabs)
+	}
+	tab, err := QueryOne[genericTab](client, "SELECT id, name, created_time, note FROM tab")
+	if err != nil || tab.Id != 1 {
+		t.Error("Query one:", tab, err)
+	}
+	name, err := QueryOne[string](client, "SELECT name FROM tab WHERE id = ?", 1)
+	if err != nil || name != "tony" {
+		t.Error("Query one scalar:", name, err)
+	}
+	if _, err = QueryOne[string](client, "SELECT name FROM tab WHERE id = 0"); err != db.ErrNoRows {
+		t.Error("Query one no rows:", err)
+	}
+
+	byId, err := QueryMap[int64, genericTab](client, "SELECT id, name FROM tab")
+	if err != nil || len(byId) != 2 || byId[2].Id != 2 || byId[2].Name != "tina" {
+		t.Error("Query map:", byId, err)
+	}
+	nameById, err := QueryMap[int64, string](client, "SELECT id, name FROM tab")
+	if err != nil || nameById[1] != "tony" {
+		t.Error("Query map scalar:", nameById, err)
+	}
+
+	if _, err = QueryAll[genericTab](client, "SELECT id, age FROM tab"); err == nil || !strings.Contains(err.Error(), "'age'") {
+		t.Error("Query unknown column:", err)
+	}
+	if _, err = QueryAll[int64](client, "SELECT id, age FROM tab"); err == nil {
+		t.Error("Query scalar of 2 columns")
+	}
+	if open := fake.getOpenStmts(); open != 0 {
+		t.Error("Query open stmts:", open)
+	}
+}
@@ -0,0 +1,233 @@
+package tsgmysqlutils
+
+/*
+ The helpers of the tests without a MySQL server
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// Get a client without a connection, its statements are run by runQuery only
+func newTestClient() *DBClient {
+	client := new(DBClient)
+	client.Logger = NewNopLogger()
+	client.Config.DbHost = "127.0.0.1"
+	client.Config.DbName = "test"
+	return client
+}
+
+// The fake driver of the tests without a MySQL server
+type fakeResult struct {
+	columns      []string
+	types        []string
+	nullable     []bool
+	rows         [][]driver.Value
+	err          error
+	lastInsertId int64
+	rowsAffected int64
+}
+
+type fakeDB struct {
+	lock      sync.Mutex
+	results   map[string]fakeResult
+	log       []string
+	openStmts int
+	// the errors of the next commits
+	commitErrs []error
+}
+
+var fakeDBs sync.Map
+
+func init() {
+	db.Register("tsgfake", fakeDriver{})
+}
+
+// Get a client of the fake driver, the unknown queries fail, the unknown execs affect 1 row
+func newFakeClient(t *testing.T, results map[string]fakeResult) (*DBClient, *fakeDB) {
+	fake := &fakeDB{results: results}
+	fakeDBs.Store(t.Name(), fake)
+	sqlDb, err := db.Open("tsgfake", t.Name())
+	if err != nil {
+		t.Fatal("Open fake db:", err)
+	}
+	client := newTestClient()
+	client.Db = sqlDb
+	t.Cleanup(func() {
+		client.CloseConn()
+		fakeDBs.Delete(t.Name())
+	})
+	return client, fake
+}
+
+func (fake *fakeDB) record(entry string) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	fake.log = append(fake.log, entry)
+}
+
+func (fake *fakeDB) getLog() []string {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	return append([]string(nil), fake.log...)
+}
+
+func (fake *fakeDB) getOpenStmts() int {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	return fake.openStmts
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fake, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, errors.New("fake: unknown db " + name)
+	}
+	return &fakeConn{fake.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	fake *fakeDB
+}
+
+func (conn *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	conn.fake.lock.Lock()
+	conn.fake.openStmts++
+	conn.fake.lock.Unlock()
+	return &fakeStmt{conn.fake, query}, nil
+}
+
+func (conn *fakeConn) Close() error {
+	return nil
+}
+
+func (conn *fakeConn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	conn.fake.record(fmt.Sprintf("begin isolation=%d read_only=%t", opts.Isolation, opts.ReadOnly))
+	return &fakeTx{conn.fake}, nil
+}
+
+type fakeTx struct {
+	fake *fakeDB
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.fake.record("commit")
+	tx.fake.lock.Lock()
+	defer tx.fake.lock.Unlock()
+	if len(tx.fake.commitErrs) == 0 {
+		return nil
+	}
+	err := tx.fake.commitErrs[0]
+	tx.fake.commitErrs = tx.fake.commitErrs[1:]
+	return err
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.fake.record("rollback")
+	return nil
+}
+
+type fakeStmt struct {
+	fake  *fakeDB
+	query string
+}
+
+func (stmt *fakeStmt) Close() error {
+	stmt.fake.lock.Lock()
+	stmt.fake.openStmts--
+	stmt.fake.lock.Unlock()
+	return nil
+}
+
+func (stmt *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.fake.record(fmt.Sprint("exec ", stmt.query, " ", args))
+	result, ok := stmt.fake.results[stmt.query]
+	if !ok {
+		return fakeExecResult{1, 1}, nil
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return fakeExecResult{result.lastInsertId, result.rowsAffected}, nil
+}
+
+func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stmt.fake.record(fmt.Sprint("query ", stmt.query, " ", args))
+	result, ok := stmt.fake.results[stmt.query]
+	if !ok {
+		return nil, errors.New("fake: unknown query " + stmt.query)
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeExecResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (result fakeExecResult) LastInsertId() (int64, error) {
+	return result.lastInsertId, nil
+}
+
+func (result fakeExecResult) RowsAffected() (int64, error) {
+	return result.rowsAffected, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (rows *fakeRows) Columns() []string {
+	return rows.result.columns
+}
+
+func (rows *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(rows.result.types) {
+		return rows.result.types[index]
+	}
+	return ""
+}
+
+func (rows *fakeRows) ColumnTypeNullable(index int) (bool, bool) {
+	if index < len(rows.result.nullable) {
+		return rows.result.nullable[index], true
+	}
+	return false, false
+}
+
+func (rows *fakeRows) Close() error {
+	return nil
+}
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if rows.next >= len(rows.result.rows) {
+		return io.EOF
+	}
+	copy(dest, rows.result.rows[rows.next])
+	rows.next++
+	return nil
+}
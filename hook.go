@@ -0,0 +1,214 @@
+package tsgmysqlutils
+
+/*
+ Query hooks: the middleware chain around every statement of QueryRow, QueryList, Exec and the Tx* variants
+  Usage:
+	client.AddHook(tsgmysqlutils.HookFuncs{
+		After: func(ctx context.Context, event *tsgmysqlutils.QueryEvent) {
+			audit(event.Op, event.Sql, event.SafeArgs(), event.Duration, event.Err)
+		},
+	})
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"time"
+)
+
+/*
+  The statement operations of the QueryEvent
+*/
+const (
+	OpQueryRow  = "QueryRow"
+	OpQueryList = "QueryList"
+	OpExec      = "Exec"
+	// the whole stream of ForEach and QueryIter
+	OpQueryStream = "QueryStream"
+)
+
+/*
+  The QueryEvent.Err of the AfterQuery hooks if the statement panics, the panic goes on after the hooks
+*/
+var errQueryPanic = errors.New(MySQL + " query panicked")
+
+/*
+  A statement executed by a client, BeforeQuery may rewrite the Sql and Args,
+  they are bound by BindArgs after BeforeQuery except the sqls of the builders, AfterQuery sees the bound Sql and Args
+*/
+type QueryEvent struct {
+	Client *DBClient
+	Op     string
+	Sql    string
+	Args   []interface{}
+	// the transaction of the Tx* methods, nil if not in a transaction
+	Tx   *db.Tx
+	InTx bool
+	// set before AfterQuery
+	Start    time.Time
+	Duration time.Duration
+	// Exec: the rows affected, the queries: -1
+	RowsAffected int64
+	// the statement errors are wrapped by SqlError
+	Err error
+	// the slice args expanded by BindArgs, the statement is not cached, see DBConfig.StmtCacheSize
+	expanded bool
+	// QueryRow: the query error deferred to Scan by sql.Row, AfterQuery sees it as Err, the caller still gets it on Scan
+	rowErr error
+}
+
+/*
+  The args redacted by the client Redactor, use it in the logs, traces and audits
+*/
+func (event *QueryEvent) SafeArgs() []interface{} {
+	var redactor *Redactor
+	if event.Client != nil {
+		redactor = event.Client.Redactor
+	}
+	return redactor.Redact(event.Sql, event.Args)
+}
+
+/*
+  The hook around every statement of a client:
+  BeforeQuery is called in the order added, return the context of the statement, a non-nil error aborts the statement,
+  AfterQuery is called in the reverse order, for each hook whose BeforeQuery was called
+*/
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error)
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+/*
+  A QueryHook by functions, the nil functions are skipped
+*/
+type HookFuncs struct {
+	Before func(ctx context.Context, event *QueryEvent) (context.Context, error)
+	After  func(ctx context.Context, event *QueryEvent)
+}
+
+func (hook HookFuncs) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	if hook.Before == nil {
+		return ctx, nil
+	}
+	return hook.Before(ctx, event)
+}
+
+func (hook HookFuncs) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if hook.After != nil {
+		hook.After(ctx, event)
+	}
+}
+
+/*
+  Add the hooks after the built-in tracing, metrics and slow sql hooks, the hooks added before
+*/
+func (client *DBClient) AddHook(hooks ...QueryHook) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.hooks = append(client.hooks, hooks...)
+}
+
+/*
+  Get the hooks of the client: the built-in tracing, metrics and slow sql hooks, the hooks added
+*/
+func (client *DBClient) getHooks() []QueryHook {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	hooks := make([]QueryHook, 0, len(client.hooks)+3)
+	if client.Tracer != nil {
+		hooks = append(hooks, tracingHook{client.Tracer})
+	}
+	if client.Metrics != nil {
+		hooks = append(hooks, metricsHook{client.Metrics})
+	}
+	hooks = append(hooks, slowSqlHook{})
+	return append(hooks, client.hooks...)
+}
+
+func newQueryEvent(op string, tx *db.Tx, sql string, args []interface{}) *QueryEvent {
+	var event QueryEvent
+	event.Op = op
+	event.Sql = sql
+	event.Args = args
+	event.Tx = tx
+	event.InTx = tx != nil
+	event.RowsAffected = -1
+	return &event
+}
+
+/*
+  Run the statement by the hooks chain, the statement uses the event Sql and Args bound by BindArgs after BeforeQuery,
+  the BeforeQuery hooks may rewrite the named placeholders and the slice args, the bind errors are sent to AfterQuery,
+  the args of the bound sqls are not bound again, see contextWithBoundSql
+*/
+func (client *DBClient) runQuery(ctx context.Context, event *QueryEvent, query func(ctx context.Context) error) error {
+	hooks := client.getHooks()
+	bound := isBoundSql(ctx, event.Sql)
+	event.Client = client
+	event.Start = time.Now()
+	called := 0
+	var err error
+	for _, hook := range hooks {
+		var hookCtx context.Context
+		hookCtx, err = hook.BeforeQuery(ctx, event)
+		if hookCtx != nil {
+			ctx = hookCtx
+		}
+		called++
+		if err != nil {
+			break
+		}
+	}
+	if err == nil && !bound {
+		var sql string
+		var args []interface{}
+		if sql, args, event.expanded, err = bindArgs(event.Sql, event.Args); err == nil {
+			event.Sql, event.Args = sql, args
+		} else {
+			client.logErrorSql(err, event.Sql)
+			err = wrapSqlError(event.Op, event.Sql, err)
+		}
+	}
+	if err == nil {
+		panicked := true
+		defer func() {
+			// the AfterQuery hooks see the statement panics, eg: the ForEach callbacks
+			if panicked {
+				event.Duration = time.Since(event.Start)
+				event.Err = errQueryPanic
+				for i := called - 1; i >= 0; i-- {
+					hooks[i].AfterQuery(ctx, event)
+				}
+			}
+		}()
+		err = wrapSqlError(event.Op, event.Sql, query(ctx))
+		panicked = false
+	}
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+	if err == nil && event.rowErr != nil {
+		event.Err = wrapSqlError(event.Op, event.Sql, event.rowErr)
+	}
+	for i := called - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctx, event)
+	}
+	return err
+}
+
+/*
+  The built-in hook logs the slow and canceled sqls
+*/
+type slowSqlHook struct{}
+
+func (slowSqlHook) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+func (slowSqlHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	event.Client.slowSql(ctx, event.Duration.Milliseconds(), event.Sql, event.Args...)
+}
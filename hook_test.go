@@ -0,0 +1,134 @@
+package tsgmysqlutils
+
+/*
+ Query hooks test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestQueryHook(t *testing.T) {
+	type hookKey struct{}
+	client := newTestClient()
+	var calls []string
+	client.AddHook(HookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			calls = append(calls, "before1")
+			event.Sql = "/* hooked */ " + event.Sql
+			return context.WithValue(ctx, hookKey{}, "value"), nil
+		},
+		After: func(ctx context.Context, event *QueryEvent) {
+			calls = append(calls, "after1")
+		},
+	}, HookFuncs{
+		After: func(ctx context.Context, event *QueryEvent) {
+			calls = append(calls, "after2")
+		},
+	})
+	event := newQueryEvent(OpExec, nil, "DELETE FROM user WHERE id = ?", []interface{}{1})
+	err := client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		calls = append(calls, "query")
+		if ctx.Value(hookKey{}) != "value" || event.Sql != "/* hooked */ DELETE FROM user WHERE id = ?" {
+			t.Error("Hook context:", event.Sql)
+		}
+		event.RowsAffected = 1
+		return nil
+	})
+	if err != nil || fmt.Sprint(calls) != "[before1 query after2 after1]" || event.RowsAffected != 1 || event.InTx {
+		t.Error("Hook calls:", calls, err, event)
+	}
+
+	aborted := errors.New("aborted by hook")
+	calls = nil
+	client.AddHook(HookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			calls = append(calls, "before3")
+			return ctx, aborted
+		},
+	}, HookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			calls = append(calls, "before4")
+			return ctx, nil
+		},
+	})
+	event = newQueryEvent(OpQueryRow, nil, "SELECT 1", nil)
+	err = client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		calls = append(calls, "query")
+		return nil
+	})
+	if err != aborted || event.Err != aborted || fmt.Sprint(calls) != "[before1 before3 after2 after1]" {
+		t.Error("Hook abort:", calls, err)
+	}
+
+	// the args are bound after BeforeQuery, the bind errors are sent to AfterQuery
+	hooked := newTestClient()
+	var afterErr error
+	hooked.AddHook(HookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			event.Sql += " AND tenant_id = :tenant_id"
+			event.Args[0].(map[string]interface{})["tenant_id"] = 9
+			return ctx, nil
+		},
+		After: func(ctx context.Context, event *QueryEvent) {
+			afterErr = event.Err
+		},
+	})
+	event = newQueryEvent(OpExec, nil, "DELETE FROM user WHERE id IN (:ids)", []interface{}{map[string]interface{}{"ids": []int{1, 2}}})
+	err = hooked.runQuery(context.Background(), event, func(ctx context.Context) error { return nil })
+	if bound := fmt.Sprint(event.Sql, " ", event.Args); err != nil || bound != "DELETE FROM user WHERE id IN (?, ?) AND tenant_id = ? [1 2 9]" {
+		t.Error("Hook bind args:", bound, err)
+	}
+	event = newQueryEvent(OpExec, nil, "DELETE FROM user WHERE id IN (:ids)", []interface{}{map[string]interface{}{"ids": []int{}}})
+	err = hooked.runQuery(context.Background(), event, func(ctx context.Context) error {
+		t.Error("Hook bind error queried")
+		return nil
+	})
+	var sqlErr *SqlError
+	if !errors.As(err, &sqlErr) || sqlErr.Op != OpExec || afterErr != err {
+		t.Error("Hook bind error:", err, afterErr)
+	}
+
+	client.Redactor = NewRedactor().RedactColumn("password")
+	event = newQueryEvent(OpExec, nil, "UPDATE user SET password = ? WHERE id = ?", []interface{}{"123456", 1})
+	event.Client = client
+	if safeArgs := fmt.Sprint(event.SafeArgs()); safeArgs != "[****** 1]" {
+		t.Error("Hook safe args:", safeArgs)
+	}
+
+	// the QueryRow errors are deferred to Scan for the callers, AfterQuery sees them
+	rowSql := "SELECT name FROM user WHERE id = ?"
+	rowFailed := errors.New("test row error")
+	fakeClient, _ := newFakeClient(t, map[string]fakeResult{rowSql: {err: rowFailed}})
+	var rowErrs []error
+	fakeClient.AddHook(HookFuncs{
+		After: func(ctx context.Context, event *QueryEvent) {
+			rowErrs = append(rowErrs, event.Err)
+		},
+	})
+	tx, err := fakeClient.TxBegin()
+	if err != nil {
+		t.Fatal("Tx begin:", err)
+	}
+	defer fakeClient.TxRollback(tx)
+	for _, query := range []func() (*db.Row, error){
+		func() (*db.Row, error) { return fakeClient.QueryRow(nil, rowSql, 1) },
+		func() (*db.Row, error) { return fakeClient.TxQueryRow(tx, nil, rowSql, 1) },
+	} {
+		rowErrs = nil
+		row, err := query()
+		if err != nil || row == nil || !errors.Is(row.Scan(new(string)), rowFailed) {
+			t.Error("Hook row scan error:", err)
+		}
+		if len(rowErrs) != 1 || !errors.Is(rowErrs[0], rowFailed) || ErrorSql(rowErrs[0]) != rowSql {
+			t.Error("Hook row error:", rowErrs)
+		}
+	}
+}
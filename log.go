@@ -2,56 +2,230 @@ package tsgmysqlutils
 
 /*
  log utils
+  Usage:
+	// structured JSON logs of a client
+	client.Logger = tsgmysqlutils.NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	// only errors
+	client.LogLevel = tsgmysqlutils.LevelError
+	// silence
+	client.Logger = tsgmysqlutils.NewNopLogger()
+	// all clients without a Logger
+	tsgmysqlutils.DefaultLogger = tsgmysqlutils.NewStdLogger(log.New(os.Stderr, "[mysql] ", log.LstdFlags))
+
  @author Tony Tian
  @date 2018-04-16
  @version 1.0.0
 */
 
 import (
+	"context"
+	"fmt"
 	"github.com/timespacegroup/go-utils"
 	"log"
+	"log/slog"
+	"time"
 )
 
-func PrintSlowConn(driverName, host, dbName string, consume int64) {
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	// a client with LogLevel = LevelOff logs nothing
+	LevelOff
+)
+
+func (level LogLevel) String() string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelOff:
+		return "OFF"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(level))
+}
+
+/*
+  The structured log field keys
+*/
+const (
+	LogKeyDriver   = "driver"
+	LogKeyHost     = "host"
+	LogKeyDbName   = "db"
+	LogKeyDuration = "duration"
+	LogKeySql      = "sql"
+	LogKeyArgCount = "arg_count"
+	LogKeyArgs     = "args"
+	LogKeyError    = "error"
+)
+
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+/*
+  The structured logger, implement it to redirect the client logs
+*/
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+/*
+  The logger of the Print functions and the clients without a Logger
+*/
+var DefaultLogger Logger = NewStdLogger(nil)
+
+/*
+  Get a logger writes "[LEVEL] msg: key=value, key=value" lines, if logger is nil, the standard log
+*/
+func NewStdLogger(logger *log.Logger) Logger {
+	return &stdLogger{logger: logger}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+func (std *stdLogger) Log(level LogLevel, msg string, fields ...LogField) {
 	builder := tsgutils.NewStringBuilder()
-	builder.Append(driverName)
-	builder.Append(" Conn Timeout: ")
-	builder.Append("Host: ")
-	builder.Append(host)
-	builder.Append(", DBName: ")
-	builder.Append(dbName)
-	builder.Append(", Consume time: ")
-	builder.AppendInt64(consume)
-	builder.Append("ms")
-	log.Println(builder.ToString())
+	builder.Append("[").Append(level.String()).Append("] ").Append(msg)
+	for i, field := range fields {
+		if i == 0 {
+			builder.Append(": ")
+		} else {
+			builder.Append(", ")
+		}
+		builder.Append(field.Key).Append("=").Append(fmt.Sprint(field.Value))
+	}
+	if std.logger == nil {
+		log.Println(builder.ToString())
+	} else {
+		std.logger.Println(builder.ToString())
+	}
+}
+
+/*
+  Get a logger writes to the log/slog logger, if logger is nil, slog.Default()
+*/
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (sl *slogLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	logger := sl.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var slogLevel slog.Level
+	switch level {
+	case LevelDebug:
+		slogLevel = slog.LevelDebug
+	case LevelInfo:
+		slogLevel = slog.LevelInfo
+	case LevelWarn:
+		slogLevel = slog.LevelWarn
+	default:
+		slogLevel = slog.LevelError
+	}
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok {
+			attrs = append(attrs, slog.String(field.Key, err.Error()))
+		} else {
+			attrs = append(attrs, slog.Any(field.Key, field.Value))
+		}
+	}
+	logger.LogAttrs(context.Background(), slogLevel, msg, attrs...)
+}
+
+/*
+  Get a logger discards all logs
+*/
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level LogLevel, msg string, fields ...LogField) {}
+
+func slowConnFields(driverName, host, dbName string, consume int64) []LogField {
+	return []LogField{
+		{LogKeyDriver, driverName},
+		{LogKeyHost, host},
+		{LogKeyDbName, dbName},
+		{LogKeyDuration, time.Duration(consume) * time.Millisecond},
+	}
+}
+
+/*
+  The sql log fields, the args are redacted by the redactor, see Redactor.Redact
+*/
+func sqlFields(redactor *Redactor, host, dbName string, consume int64, err error, sql string, args ...interface{}) []LogField {
+	args = redactor.Redact(sql, args)
+	var fields []LogField
+	if host != "" || dbName != "" {
+		fields = append(fields, LogField{LogKeyHost, host}, LogField{LogKeyDbName, dbName})
+	}
+	if consume >= 0 {
+		fields = append(fields, LogField{LogKeyDuration, time.Duration(consume) * time.Millisecond})
+	}
+	fields = append(fields, LogField{LogKeySql, sql}, LogField{LogKeyArgCount, len(args)}, LogField{LogKeyArgs, args})
+	if err != nil {
+		fields = append(fields, LogField{LogKeyError, err})
+	}
+	return fields
+}
+
+func PrintSlowConn(driverName, host, dbName string, consume int64) {
+	DefaultLogger.Log(LevelWarn, "slow conn", slowConnFields(driverName, host, dbName, consume)...)
 }
 
 func PrintErrorSql(err error, sql string, args ...interface{}) {
 	if err != nil {
-		log.Println("Error Sql: ", sql)
-		log.Print("Error Sql Args: ")
-		log.Println(args...)
+		DefaultLogger.Log(LevelError, "error sql", sqlFields(nil, "", "", -1, err, sql, args...)...)
 	}
 }
 
 func PrintSlowSql(host, dbName string, consume int64, sql string, args ...interface{}) {
-	builder := tsgutils.NewStringBuilder()
-	builder.Append("Slow Sql: ")
-	builder.Append("Host: ")
-	builder.Append(host)
-	builder.Append(", DBName: ")
-	builder.Append(dbName)
-	builder.Append(", Consume time: ")
-	builder.AppendInt64(consume)
-	builder.Append("ms")
-	log.Println(builder.ToString())
-
-	builder.Clear()
-	builder.Append("Slow Sql: ")
-	builder.Append(sql)
-	log.Println(builder.ToString())
-
-	builder.Clear()
-	log.Print("Slow Sql Args: ")
-	log.Println(args...)
+	DefaultLogger.Log(LevelWarn, "slow sql", sqlFields(nil, host, dbName, consume, nil, sql, args...)...)
+}
+
+func PrintCanceledSql(host, dbName string, consume int64, cause error, sql string, args ...interface{}) {
+	DefaultLogger.Log(LevelWarn, "canceled sql", sqlFields(nil, host, dbName, consume, cause, sql, args...)...)
+}
+
+/*
+  Log by the client Logger, or DefaultLogger if nil, skip the logs below the client LogLevel
+*/
+func (client *DBClient) log(level LogLevel, msg string, fields ...LogField) {
+	if level < client.LogLevel || client.LogLevel >= LevelOff {
+		return
+	}
+	logger := client.Logger
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	logger.Log(level, msg, fields...)
+}
+
+func (client *DBClient) logErrorSql(err error, sql string, args ...interface{}) {
+	client.log(LevelError, "error sql", sqlFields(client.Redactor, client.Config.DbHost, client.Config.DbName, -1, err, sql, args...)...)
+}
+
+func (client *DBClient) logError(msg string, err error) {
+	client.log(LevelError, msg, LogField{LogKeyHost, client.Config.DbHost}, LogField{LogKeyDbName, client.Config.DbName}, LogField{LogKeyError, err})
 }
@@ -0,0 +1,388 @@
+package tsgmysqlutils
+
+/*
+ Prometheus compatible metrics of the queries, errors and connection pools
+  Usage:
+	metrics := tsgmysqlutils.NewMetrics()
+	metrics.Register(client1, client2)
+	http.Handle("/metrics", metrics)
+
+	// the Go API
+	snapshot := metrics.Snapshot()
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"bufio"
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+  The metric names of the text exposition
+*/
+const (
+	MetricQueries             = "mysql_queries_total"
+	MetricQueryDuration       = "mysql_query_duration_seconds"
+	MetricQueryErrors         = "mysql_query_errors_total"
+	MetricPoolOpen            = "mysql_pool_open_connections"
+	MetricPoolInUse           = "mysql_pool_in_use_connections"
+	MetricPoolIdle            = "mysql_pool_idle_connections"
+	MetricPoolMaxOpen         = "mysql_pool_max_open_connections"
+	MetricPoolSaturation      = "mysql_pool_saturation"
+	MetricPoolWaits           = "mysql_pool_wait_total"
+	MetricPoolWaitDuration    = "mysql_pool_wait_duration_seconds_total"
+	MetricsContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	ErrorCodeConn             = "conn"
+	ErrorCodeCanceled         = "canceled"
+	ErrorCodeDeadlineExceeded = "deadline_exceeded"
+	ErrorCodeOther            = "other"
+)
+
+/*
+  The default query latency histogram buckets, seconds
+*/
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+/*
+  The metrics collector of the clients, labelled by the DBConfig host and database
+*/
+type Metrics struct {
+	buckets []float64
+	lock    sync.Mutex
+	queries map[queryLabels]*QueryMetric
+	errors  map[errorLabels]int64
+	clients []*DBClient
+}
+
+type queryLabels struct {
+	host   string
+	dbName string
+	op     string
+}
+
+type errorLabels struct {
+	queryLabels
+	code string
+}
+
+/*
+  The statements of an operation of a client
+*/
+type QueryMetric struct {
+	Host   string
+	DbName string
+	Op     string
+	Count  int64
+	Errors int64
+	// the total duration
+	Sum time.Duration
+	// the histogram upper bounds, seconds
+	Buckets []float64
+	// the cumulative statements of each bucket
+	BucketCounts []int64
+}
+
+/*
+  The errors of an operation of a client by the error code: the MySQL error number, ErrorCodeConn,
+  ErrorCodeCanceled, ErrorCodeDeadlineExceeded or ErrorCodeOther
+*/
+type ErrorMetric struct {
+	Host   string
+	DbName string
+	Op     string
+	Code   string
+	Count  int64
+}
+
+type MetricsSnapshot struct {
+	Queries []QueryMetric
+	Errors  []ErrorMetric
+	Pools   []PoolStats
+}
+
+/*
+  Get a metrics collector, if no buckets, DefaultLatencyBuckets
+*/
+func NewMetrics(buckets ...float64) *Metrics {
+	var metrics Metrics
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	metrics.buckets = make([]float64, len(buckets))
+	copy(metrics.buckets, buckets)
+	sort.Float64s(metrics.buckets)
+	metrics.queries = make(map[queryLabels]*QueryMetric)
+	metrics.errors = make(map[errorLabels]int64)
+	return &metrics
+}
+
+/*
+  Record the queries of the clients and collect their connection pool statistics
+*/
+func (metrics *Metrics) Register(clients ...*DBClient) {
+	for _, client := range clients {
+		client.lock.Lock()
+		client.Metrics = metrics
+		client.lock.Unlock()
+	}
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	for _, client := range clients {
+		registered := false
+		for _, c := range metrics.clients {
+			registered = registered || c == client
+		}
+		if !registered {
+			metrics.clients = append(metrics.clients, client)
+		}
+	}
+}
+
+/*
+  Record a statement
+*/
+func (metrics *Metrics) Observe(host, dbName, op string, duration time.Duration, err error) {
+	labels := queryLabels{host, dbName, op}
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	metric, ok := metrics.queries[labels]
+	if !ok {
+		metric = &QueryMetric{Host: host, DbName: dbName, Op: op, Buckets: metrics.buckets, BucketCounts: make([]int64, len(metrics.buckets))}
+		metrics.queries[labels] = metric
+	}
+	metric.Count++
+	metric.Sum += duration
+	seconds := duration.Seconds()
+	for i, bucket := range metric.Buckets {
+		if seconds <= bucket {
+			metric.BucketCounts[i]++
+		}
+	}
+	if err != nil {
+		metric.Errors++
+		metrics.errors[errorLabels{labels, ErrorCode(err)}]++
+	}
+}
+
+/*
+  Get the metric error code: the MySQL error number, eg: "1062", ErrorCodeConn, ErrorCodeCanceled,
+  ErrorCodeDeadlineExceeded or ErrorCodeOther, "" if err is nil
+*/
+func ErrorCode(err error) string {
+	if number, ok := MySQLErrorNumber(err); ok {
+		return strconv.Itoa(int(number))
+	}
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeDeadlineExceeded
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn), errors.Is(err, db.ErrConnDone):
+		return ErrorCodeConn
+	}
+	return ErrorCodeOther
+}
+
+/*
+  Get the metrics snapshot sorted by the labels, the pool statistics of the registered clients
+*/
+func (metrics *Metrics) Snapshot() MetricsSnapshot {
+	var snapshot MetricsSnapshot
+	metrics.lock.Lock()
+	for _, metric := range metrics.queries {
+		query := *metric
+		query.BucketCounts = make([]int64, len(metric.BucketCounts))
+		copy(query.BucketCounts, metric.BucketCounts)
+		snapshot.Queries = append(snapshot.Queries, query)
+	}
+	for labels, count := range metrics.errors {
+		snapshot.Errors = append(snapshot.Errors, ErrorMetric{labels.host, labels.dbName, labels.op, labels.code, count})
+	}
+	clients := make([]*DBClient, len(metrics.clients))
+	copy(clients, metrics.clients)
+	metrics.lock.Unlock()
+
+	for _, client := range clients {
+		snapshot.Pools = append(snapshot.Pools, client.PoolStats())
+	}
+	sort.Slice(snapshot.Queries, func(i, j int) bool {
+		a, b := snapshot.Queries[i], snapshot.Queries[j]
+		return labelsLess([]string{a.Host, a.DbName, a.Op}, []string{b.Host, b.DbName, b.Op})
+	})
+	sort.Slice(snapshot.Errors, func(i, j int) bool {
+		a, b := snapshot.Errors[i], snapshot.Errors[j]
+		return labelsLess([]string{a.Host, a.DbName, a.Op, a.Code}, []string{b.Host, b.DbName, b.Op, b.Code})
+	})
+	sort.SliceStable(snapshot.Pools, func(i, j int) bool {
+		a, b := snapshot.Pools[i], snapshot.Pools[j]
+		return labelsLess([]string{a.Host, a.DbName}, []string{b.Host, b.DbName})
+	})
+	return snapshot
+}
+
+func labelsLess(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+/*
+  Write the metrics in the Prometheus text exposition format
+*/
+func (metrics *Metrics) WriteText(w io.Writer) error {
+	snapshot := metrics.Snapshot()
+	writer := bufio.NewWriter(w)
+	header := func(name, help, metricType string) {
+		fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	}
+	sample := func(name string, value interface{}, labels ...string) {
+		writer.WriteString(name)
+		writer.WriteString(metricLabels(labels...))
+		writer.WriteString(" ")
+		switch v := value.(type) {
+		case float64:
+			writer.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			fmt.Fprint(writer, v)
+		}
+		writer.WriteString("\n")
+	}
+
+	if len(snapshot.Queries) > 0 {
+		header(MetricQueries, "The statements executed.", "counter")
+		for _, query := range snapshot.Queries {
+			sample(MetricQueries, query.Count, "host", query.Host, "db", query.DbName, "op", query.Op)
+		}
+		header(MetricQueryDuration, "The statement latencies.", "histogram")
+		for _, query := range snapshot.Queries {
+			for i, bucket := range query.Buckets {
+				le := strconv.FormatFloat(bucket, 'g', -1, 64)
+				sample(MetricQueryDuration+"_bucket", query.BucketCounts[i], "host", query.Host, "db", query.DbName, "op", query.Op, "le", le)
+			}
+			sample(MetricQueryDuration+"_bucket", query.Count, "host", query.Host, "db", query.DbName, "op", query.Op, "le", "+Inf")
+			sample(MetricQueryDuration+"_sum", query.Sum.Seconds(), "host", query.Host, "db", query.DbName, "op", query.Op)
+			sample(MetricQueryDuration+"_count", query.Count, "host", query.Host, "db", query.DbName, "op", query.Op)
+		}
+	}
+	if len(snapshot.Errors) > 0 {
+		header(MetricQueryErrors, "The statement errors by the MySQL error number or the error kind.", "counter")
+		for _, e := range snapshot.Errors {
+			sample(MetricQueryErrors, e.Count, "host", e.Host, "db", e.DbName, "op", e.Op, "code", e.Code)
+		}
+	}
+	if len(snapshot.Pools) > 0 {
+		pools := []struct {
+			name, help, metricType string
+			value                  func(stats PoolStats) interface{}
+		}{
+			{MetricPoolOpen, "The established connections.", "gauge", func(stats PoolStats) interface{} { return stats.OpenConnections }},
+			{MetricPoolInUse, "The connections in use.", "gauge", func(stats PoolStats) interface{} { return stats.InUse }},
+			{MetricPoolIdle, "The idle connections.", "gauge", func(stats PoolStats) interface{} { return stats.Idle }},
+			{MetricPoolMaxOpen, "The max open connections, 0 if unlimited.", "gauge", func(stats PoolStats) interface{} { return stats.MaxOpenConnections }},
+			{MetricPoolSaturation, "The in use connections ratio of the max open connections.", "gauge", func(stats PoolStats) interface{} { return stats.Saturation() }},
+			{MetricPoolWaits, "The connections waited for.", "counter", func(stats PoolStats) interface{} { return stats.WaitCount }},
+			{MetricPoolWaitDuration, "The time blocked waiting for a connection.", "counter", func(stats PoolStats) interface{} { return stats.WaitDuration.Seconds() }},
+		}
+		for _, pool := range pools {
+			header(pool.name, pool.help, pool.metricType)
+			for _, stats := range sumPoolStats(snapshot.Pools) {
+				sample(pool.name, pool.value(stats), "host", stats.Host, "db", stats.DbName)
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+/*
+  Sum the pool statistics sorted by the labels of the clients with the same host and database, eg: a read and a write pool,
+  a series per labels, the max open connections are unlimited if any is unlimited
+*/
+func sumPoolStats(pools []PoolStats) []PoolStats {
+	var sums []PoolStats
+	for _, stats := range pools {
+		n := len(sums)
+		if n == 0 || sums[n-1].Host != stats.Host || sums[n-1].DbName != stats.DbName {
+			sums = append(sums, stats)
+			continue
+		}
+		sum := &sums[n-1]
+		if sum.MaxOpenConnections <= 0 || stats.MaxOpenConnections <= 0 {
+			sum.MaxOpenConnections = 0
+		} else {
+			sum.MaxOpenConnections += stats.MaxOpenConnections
+		}
+		sum.OpenConnections += stats.OpenConnections
+		sum.InUse += stats.InUse
+		sum.Idle += stats.Idle
+		sum.WaitCount += stats.WaitCount
+		sum.WaitDuration += stats.WaitDuration
+		sum.MaxIdleClosed += stats.MaxIdleClosed
+		sum.MaxIdleTimeClosed += stats.MaxIdleTimeClosed
+		sum.MaxLifetimeClosed += stats.MaxLifetimeClosed
+	}
+	return sums
+}
+
+/*
+  The text exposition handler, eg: http.Handle("/metrics", metrics)
+*/
+func (metrics *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", MetricsContentType)
+	metrics.WriteText(w)
+}
+
+/*
+  {key="value",key="value"}, the values escaped
+*/
+func metricLabels(pairs ...string) string {
+	builder := strings.Builder{}
+	builder.WriteString("{")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(pairs[i])
+		builder.WriteString(`="`)
+		builder.WriteString(metricLabelEscaper.Replace(pairs[i+1]))
+		builder.WriteString(`"`)
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/*
+  The built-in hook records the statements of the client Metrics
+*/
+type metricsHook struct {
+	metrics *Metrics
+}
+
+func (hook metricsHook) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+func (hook metricsHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	hook.metrics.Observe(event.Client.Config.DbHost, event.Client.Config.DbName, event.Op, event.Duration, event.Err)
+}
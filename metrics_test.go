@@ -0,0 +1,104 @@
+package tsgmysqlutils
+
+/*
+ Query metrics test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(0.01, 0.1)
+	client := newTestClient()
+	metrics.Register(client, client)
+	if client.Metrics != metrics {
+		t.Fatal("Register metrics")
+	}
+
+	durations := []time.Duration{5 * time.Millisecond, 50 * time.Millisecond, time.Second}
+	for _, duration := range durations {
+		metrics.Observe("127.0.0.1", "test", OpExec, duration, nil)
+	}
+	event := newQueryEvent(OpExec, nil, "INSERT INTO user (id) VALUES (?)", []interface{}{1})
+	client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}
+	})
+	metrics.Observe("127.0.0.1", "test", OpQueryRow, time.Millisecond, context.Canceled)
+
+	snapshot := metrics.Snapshot()
+	if len(snapshot.Queries) != 2 || len(snapshot.Errors) != 2 || len(snapshot.Pools) != 1 {
+		t.Fatal("Metrics snapshot:", snapshot)
+	}
+	exec := snapshot.Queries[0]
+	if exec.Op != OpExec || exec.Count != 4 || exec.Errors != 1 || fmt.Sprint(exec.BucketCounts) != "[2 3]" {
+		t.Error("Exec metric:", exec)
+	}
+	if snapshot.Errors[0].Code != "1062" || snapshot.Errors[1].Code != ErrorCodeCanceled {
+		t.Error("Error metrics:", snapshot.Errors)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	text := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE mysql_query_duration_seconds histogram",
+		`mysql_queries_total{host="127.0.0.1",db="test",op="Exec"} 4`,
+		`mysql_query_duration_seconds_bucket{host="127.0.0.1",db="test",op="Exec",le="0.1"} 3`,
+		`mysql_query_duration_seconds_bucket{host="127.0.0.1",db="test",op="Exec",le="+Inf"} 4`,
+		`mysql_query_errors_total{host="127.0.0.1",db="test",op="Exec",code="1062"} 1`,
+		`mysql_pool_saturation{host="127.0.0.1",db="test"} 0`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Error("Metrics text:", line)
+		}
+	}
+	if recorder.Header().Get("Content-Type") != MetricsContentType {
+		t.Error("Metrics content type:", recorder.Header())
+	}
+	if labels := metricLabels("db", "a\"b\\c\n"); labels != `{db="a\"b\\c\n"}` {
+		t.Error("Metric labels:", labels)
+	}
+	// the pools of the clients with the same host and database are summed, eg: a read and a write pool
+	readClient, _ := newFakeClient(t, nil)
+	writeClient, _ := newFakeClient(t, nil)
+	readClient.Db.SetMaxOpenConns(2)
+	writeClient.Db.SetMaxOpenConns(3)
+	tx, err := writeClient.TxBegin()
+	if err != nil {
+		t.Fatal("Tx begin:", err)
+	}
+	defer writeClient.TxRollback(tx)
+	poolMetrics := NewMetrics()
+	poolMetrics.Register(readClient, writeClient)
+	if pools := poolMetrics.Snapshot().Pools; len(pools) != 2 {
+		t.Error("Pool snapshot:", pools)
+	}
+	var buffer bytes.Buffer
+	poolMetrics.WriteText(&buffer)
+	text = buffer.String()
+	for _, name := range []string{MetricPoolOpen, MetricPoolInUse, MetricPoolIdle, MetricPoolMaxOpen, MetricPoolSaturation, MetricPoolWaits, MetricPoolWaitDuration} {
+		if count := strings.Count(text, "\n"+name+"{"); count != 1 {
+			t.Error("Pool series:", name, count)
+		}
+	}
+	for _, line := range []string{
+		`mysql_pool_max_open_connections{host="127.0.0.1",db="test"} 5`,
+		`mysql_pool_in_use_connections{host="127.0.0.1",db="test"} 1`,
+		`mysql_pool_saturation{host="127.0.0.1",db="test"} 0.2`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Error("Pool metrics text:", line)
+		}
+	}
+}
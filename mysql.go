@@ -1,10 +1,18 @@
 package tsgmysqlutils
 
 import (
+	"context"
 	db "database/sql"
+	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/timespacegroup/go-utils"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 /*
@@ -16,6 +24,10 @@ import (
 	dbConfig.DbPass = "123456"
 	dbConfig.IsLocalTime = true
 	dbConfig.DbName = "test"
+	dbConfig.DbPort = 3306
+	dbConfig.DbCharset = "utf8mb4"
+	dbConfig.MaxOpenConns = 50
+	dbConfig.ConnMaxLifetime = time.Hour
 	client := tsgmysqlutils.NewDbClient(dbConfig)
 
    @author Tony Tian
@@ -24,14 +36,51 @@ import (
 */
 
 type DBClient struct {
-	Config DBConfig
-	Db     *db.DB
+	Config    DBConfig
+	Db        *db.DB
+	ConnStats ConnStats
+	// if nil, DefaultLogger
+	Logger Logger
+	// the logs below the level are skipped, LevelOff to silence the client
+	LogLevel LogLevel
+	// the sql args redaction rules of the logs, the SecretArg args are always redacted
+	Redactor *Redactor
+	// if nil, no tracing spans
+	Tracer Tracer
+	// if nil, no metrics, see Metrics.Register
+	Metrics *Metrics
+
+	lock    sync.Mutex
+	sampler *slowSqlSampler
+	hooks   []QueryHook
+	txSpans map[*db.Tx]*txSpan
+	// if nil, no statement cache, see DBConfig.StmtCacheSize
+	stmtCache *stmtCache
+}
+
+/*
+ MySQL connect statistics of OpenClient
+*/
+type ConnStats struct {
+	// open and ping consume time, ms
+	Consume int64
+	// ping attempts, 1 if the first ping succeeded
+	Attempts int
+	// Consume > DBConfig.SlowConnThreshold
+	Slow bool
 }
 
 const (
 	MySQL                     = "MySQL"
 	SlowSqlTimeoutMillisecond = 2000
 	ConnDBTimeoutMillisecond  = 2000
+	DefaultPort               = 3306
+	DefaultCharset            = "utf8"
+	DefaultPingTimeout        = 5 * time.Second
+	DefaultPingBackoff        = time.Second
+	MaxPingBackoff            = 30 * time.Second
+	DefaultTxRetryBackoff     = 50 * time.Millisecond
+	MaxTxRetryBackoff         = 2 * time.Second
 )
 
 /*
@@ -44,16 +93,97 @@ func NewDbClient(config DBConfig) *DBClient {
 	return &client
 }
 
+/*
+ Get a MySQL client, the same as OpenClient with a background context
+*/
+func NewDbClientE(config DBConfig) (*DBClient, error) {
+	return OpenClient(context.Background(), config)
+}
+
+/*
+ Get a MySQL client and check the connection by ping,
+ retry config.PingRetries times with a doubled backoff if the ping failed
+*/
+func OpenClient(ctx context.Context, config DBConfig) (*DBClient, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	start := tsgutils.Millisecond()
+	mysql, err := openConn(config)
+	if err != nil {
+		return nil, fmt.Errorf("%s connection failed, host: %s, db name: %s: %w", MySQL, config.DbHost, config.DbName, err)
+	}
+	attempts, err := pingConn(ctx, mysql, config)
+	var client DBClient
+	client.ConnStats.Consume = tsgutils.Millisecond() - start
+	client.ConnStats.Attempts = attempts
+	slowConnThreshold := config.getSlowConnThreshold()
+	client.ConnStats.Slow = slowConnThreshold >= 0 && client.ConnStats.Consume > slowConnThreshold
+	if client.ConnStats.Slow {
+		PrintSlowConn(MySQL, config.DbHost, config.DbName, client.ConnStats.Consume)
+	}
+	if err != nil {
+		mysql.Close()
+		return nil, fmt.Errorf("%s ping failed after %d attempts, host: %s, db name: %s: %w", MySQL, attempts, config.DbHost, config.DbName, err)
+	}
+	client.Config = config
+	client.Db = mysql
+	return &client, nil
+}
+
 /*
  MySQL database connect configuration
 */
 type DBConfig struct {
 	DbHost string
+	// if 0, default 3306
+	DbPort int
 	DbUser string
 	DbPass string
 	DbName string
+	// "tcp" or "unix", if empty, "unix" when DbSocket is set, otherwise "tcp"
+	DbProtocol string
+	// unix domain socket path, eg: /tmp/mysql.sock
+	DbSocket string
+	// if empty, default utf8, eg: utf8mb4
+	DbCharset string
+	// eg: utf8mb4_unicode_ci
+	DbCollation string
 	// if false, local default UTC +0
 	IsLocalTime bool
+	// dial, I/O read and I/O write timeouts, if 0, the driver defaults are used
+	ConnTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// extra driver params, eg: "tls": "skip-verify", the above fields take precedence
+	Params map[string]string
+	// OpenClient ping timeout of each attempt, if 0, DefaultPingTimeout
+	PingTimeout time.Duration
+	// OpenClient ping retries after the first attempt failed
+	PingRetries int
+	// OpenClient first retry backoff, doubled each retry up to MaxPingBackoff, if 0, DefaultPingBackoff
+	PingBackoff time.Duration
+	// slow sql and slow connect log thresholds, if 0, SlowSqlTimeoutMillisecond and ConnDBTimeoutMillisecond,
+	// if < 0, not logged, see WithSlowSqlThreshold for the per-call overrides
+	SlowSqlThreshold  time.Duration
+	SlowConnThreshold time.Duration
+	// the ratio of the slow sqls logged, eg: 0.1, if 0, all are logged
+	SlowSqlSampleRate float64
+	// the same slow sql is logged at most once in the interval, the suppressed count is logged next time, if 0, no limit
+	SlowSqlLogInterval time.Duration
+	// connection pool, if 0, the database/sql defaults are used:
+	// unlimited open connections, 2 idle connections, connections reused forever,
+	// if MaxIdleConns < 0, no idle connections are retained
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// the max prepared statements cached by the sql, the least recently used are closed, if 0, no cache
+	StmtCacheSize int
+	// run the statements without preparing, the driver interpolates the args, interpolateParams=true is set,
+	// the params interpolateParams=true implies it
+	SkipPrepare bool
 }
 
 /*
@@ -62,36 +192,203 @@ type DBConfig struct {
 func getDbConnString(config DBConfig) string {
 	builder := tsgutils.NewStringBuilder()
 	builder.Append(config.DbUser).Append(":").Append(config.DbPass)
-	builder.Append("@tcp(").Append(config.DbHost).Append(":").Append("3306").Append(")/")
-	builder.Append(config.DbName).Append("?").Append("charset=utf8")
-	if config.IsLocalTime {
-		builder.Append("&parseTime=true&loc=Local")
+	builder.Append("@").Append(config.getProtocol()).Append("(").Append(config.getAddress()).Append(")/")
+	builder.Append(config.DbName)
+	params := config.getConnParams()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i == 0 {
+			builder.Append("?")
+		} else {
+			builder.Append("&")
+		}
+		builder.Append(key).Append("=").Append(url.QueryEscape(params[key]))
 	}
 	return builder.ToString()
 }
 
+func (config DBConfig) getProtocol() string {
+	if config.DbProtocol != "" {
+		return config.DbProtocol
+	}
+	if config.DbSocket != "" {
+		return "unix"
+	}
+	return "tcp"
+}
+
+func (config DBConfig) getAddress() string {
+	if config.getProtocol() == "unix" {
+		return config.DbSocket
+	}
+	port := config.DbPort
+	if port == 0 {
+		port = DefaultPort
+	}
+	return net.JoinHostPort(config.DbHost, strconv.Itoa(port))
+}
+
+func (config DBConfig) getConnParams() map[string]string {
+	params := make(map[string]string, len(config.Params)+7)
+	for key, value := range config.Params {
+		params[key] = value
+	}
+	if config.DbCharset != "" {
+		params["charset"] = config.DbCharset
+	} else if params["charset"] == "" {
+		params["charset"] = DefaultCharset
+	}
+	if config.DbCollation != "" {
+		params["collation"] = config.DbCollation
+	}
+	if config.IsLocalTime {
+		params["parseTime"] = "true"
+		params["loc"] = "Local"
+	}
+	if config.ConnTimeout > 0 {
+		params["timeout"] = config.ConnTimeout.String()
+	}
+	if config.ReadTimeout > 0 {
+		params["readTimeout"] = config.ReadTimeout.String()
+	}
+	if config.WriteTimeout > 0 {
+		params["writeTimeout"] = config.WriteTimeout.String()
+	}
+	if config.SkipPrepare {
+		params["interpolateParams"] = "true"
+	}
+	return params
+}
+
 /*
   Get a MySQL connection
 */
 func GetConn(config DBConfig) *db.DB {
 	dbConnString := getDbConnString(config)
 	start := tsgutils.Millisecond()
-	mysql, err := db.Open(strings.ToLower(MySQL), dbConnString)
+	mysql, err := openConn(config)
 	consume := tsgutils.Millisecond() - start
-	if consume > ConnDBTimeoutMillisecond {
+	if slowConnThreshold := config.getSlowConnThreshold(); slowConnThreshold >= 0 && consume > slowConnThreshold {
 		PrintSlowConn(MySQL, config.DbHost, config.DbName, consume)
 	}
-	tsgutils.CheckAndPrintError(MySQL+" connection failed, db conn string: \n"+dbConnString, err)
+	tsgutils.CheckAndPrintError(MySQL+" connection failed, db conn string: \n"+MaskDSN(dbConnString), err)
 	return mysql
 }
 
+func openConn(config DBConfig) (*db.DB, error) {
+	mysql, err := db.Open(strings.ToLower(MySQL), getDbConnString(config))
+	if err != nil {
+		return nil, err
+	}
+	setConnPool(mysql, config)
+	return mysql, nil
+}
+
+/*
+  Ping the MySQL connection with retries, return the attempts
+*/
+func pingConn(ctx context.Context, mysql *db.DB, config DBConfig) (int, error) {
+	timeout := config.PingTimeout
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	backoff := config.PingBackoff
+	if backoff <= 0 {
+		backoff = DefaultPingBackoff
+	}
+	attempts := 0
+	for {
+		attempts++
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := mysql.PingContext(pingCtx)
+		cancel()
+		if err == nil || attempts > config.PingRetries {
+			return attempts, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > MaxPingBackoff {
+			backoff = MaxPingBackoff
+		}
+	}
+}
+
+/*
+  Set the connection pool of the MySQL connection
+*/
+func setConnPool(mysql *db.DB, config DBConfig) {
+	if config.MaxOpenConns > 0 {
+		mysql.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns != 0 {
+		mysql.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		mysql.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		mysql.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+}
+
+/*
+ MySQL connection pool statistics snapshot
+*/
+type PoolStats struct {
+	db.DBStats
+	Host   string
+	DbName string
+	Time   time.Time
+}
+
+/*
+  The in use connections ratio of the max open connections, 0 if unlimited
+*/
+func (stats PoolStats) Saturation() float64 {
+	if stats.MaxOpenConnections <= 0 {
+		return 0
+	}
+	return float64(stats.InUse) / float64(stats.MaxOpenConnections)
+}
+
+/*
+  Get the connection pool statistics snapshot
+*/
+func (client *DBClient) PoolStats() PoolStats {
+	var stats PoolStats
+	stats.Host = client.Config.DbHost
+	stats.DbName = client.Config.DbName
+	stats.Time = time.Now()
+	if client.Db != nil {
+		stats.DBStats = client.Db.Stats()
+	}
+	return stats
+}
+
 /*
   Get MySQL statement
 */
 func (client *DBClient) GetStmt(sql string) (stmt *db.Stmt, err error) {
-	stmt, err = client.Db.Prepare(sql)
+	return client.GetStmtContext(context.Background(), sql)
+}
+
+/*
+  Get MySQL statement,context
+*/
+func (client *DBClient) GetStmtContext(ctx context.Context, sql string) (stmt *db.Stmt, err error) {
+	stmt, err = client.Db.PrepareContext(ctx, sql)
 	if err != nil {
-		PrintErrorSql(err, sql, nil)
+		client.logErrorSql(err, sql)
 		return nil, err
 	}
 	return stmt, nil
@@ -101,6 +398,9 @@ func (client *DBClient) GetStmt(sql string) (stmt *db.Stmt, err error) {
  Close MySQL connection
 */
 func (client *DBClient) CloseConn() {
+	if cache := client.getStmtCache(); cache != nil {
+		cache.clear()
+	}
 	if client.Db != nil {
 		client.Db.Close()
 	}
@@ -119,14 +419,24 @@ func (client *DBClient) CloseStmt(stmt *db.Stmt) {
   Get database table a row data
 */
 func (client *DBClient) QueryRow(orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetStmt(sql)
-	if stmt == nil || err != nil {
-		return nil, err
-	}
-	row, err = client.forkQuery(stmt, orm, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
-	defer client.CloseStmt(stmt)
+	return client.QueryRowContext(context.Background(), orm, sql, args...)
+}
+
+/*
+  Get database table a row data,context
+*/
+func (client *DBClient) QueryRowContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
+	event := newQueryEvent(OpQueryRow, nil, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, true)
+		if stmt == nil || err != nil {
+			return err
+		}
+		row, err = client.forkQuery(ctx, stmt, orm, event.Sql, event.Args...)
+		event.rowErr = rowErr(row, err)
+		release(event.rowErr)
+		return err
+	})
 	return row, err
 }
 
@@ -134,45 +444,65 @@ func (client *DBClient) QueryRow(orm ORMBase, sql string, args ...interface{}) (
   Get database table multiple rows data
 */
 func (client *DBClient) QueryList(orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetStmt(sql)
-	if stmt == nil || err != nil {
-		return nil, err
-	}
-	rows, err = client.forkQueryList(stmt, orm, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
-	defer client.CloseStmt(stmt)
+	return client.QueryListContext(context.Background(), orm, sql, args...)
+}
+
+/*
+  Get database table multiple rows data,context
+*/
+func (client *DBClient) QueryListContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
+	event := newQueryEvent(OpQueryList, nil, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, true)
+		if stmt == nil || err != nil {
+			return err
+		}
+		rows, err = client.forkQueryList(ctx, stmt, orm, event.Sql, event.Args...)
+		release(err)
+		return err
+	})
 	return rows, err
 }
 
 /*
- Database aggregate function, eg: SUM(*),COUNT(*) etc.
+ Database aggregate function, eg: SUM(*),COUNT(*) etc, no rows: sql.ErrNoRows, NULL: ErrNullAggregate
 */
 func (client *DBClient) QueryAggregate(sql string, args ...interface{}) (aggregate int64, err error) {
-	row, err := client.QueryRow(nil, sql, args...)
+	return client.QueryAggregateContext(context.Background(), sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(*),COUNT(*) etc,context
+*/
+func (client *DBClient) QueryAggregateContext(ctx context.Context, sql string, args ...interface{}) (aggregate int64, err error) {
+	value, err := client.queryAggregate(ctx, nil, sql, args)
 	if err != nil {
 		return 0, err
 	}
-	var result int64
-	err = row.Scan(&result)
-	if err != nil {
-		return 0, nil
-	}
-	return result, nil
+	return value.Int64()
 }
 
 /*
   Modify database table info or data
 */
 func (client *DBClient) Exec(sql string, args ...interface{}) (result int64, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetStmt(sql)
-	if stmt == nil || err != nil {
-		return 0, err
-	}
-	result, err = client.forkExec(stmt, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
-	defer client.CloseStmt(stmt)
+	return client.ExecContext(context.Background(), sql, args...)
+}
+
+/*
+  Modify database table info or data,context
+*/
+func (client *DBClient) ExecContext(ctx context.Context, sql string, args ...interface{}) (result int64, err error) {
+	event := newQueryEvent(OpExec, nil, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, true)
+		if stmt == nil || err != nil {
+			return err
+		}
+		result, event.RowsAffected, err = client.forkExec(ctx, stmt, event.Sql, event.Args...)
+		release(err)
+		return err
+	})
 	return result, err
 }
 
@@ -180,38 +510,82 @@ func (client *DBClient) Exec(sql string, args ...interface{}) (result int64, err
   Begin the transaction
 */
 func (client *DBClient) TxBegin() (tx *db.Tx, err error) {
-	return client.Db.Begin()
+	return client.TxBeginContext(context.Background(), nil)
+}
+
+/*
+  Begin the transaction,context, the transaction is rolled back if the context is done before commit,
+  opts: the isolation level and read only, if nil, the driver defaults are used
+*/
+func (client *DBClient) TxBeginContext(ctx context.Context, opts *db.TxOptions) (tx *db.Tx, err error) {
+	return client.beginTx(ctx, client.Db, opts)
+}
+
+/*
+  Begin the transaction of the DB or a dedicated connection
+*/
+func (client *DBClient) beginTx(ctx context.Context, beginner interface {
+	BeginTx(ctx context.Context, opts *db.TxOptions) (*db.Tx, error)
+}, opts *db.TxOptions) (tx *db.Tx, err error) {
+	span := client.startTxSpan(ctx)
+	tx, err = beginner.BeginTx(ctx, opts)
+	if span != nil {
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+		} else {
+			client.addTxSpan(ctx, tx, span)
+		}
+	}
+	return tx, err
 }
 
 /*
   Commit the transaction
 */
 func (client *DBClient) TxCommit(tx *db.Tx) bool {
-	err := tx.Commit()
-	if err != nil {
-		tsgutils.CheckAndPrintError(MySQL+" tx commit failed", err)
-		return false
-	}
-	return true
+	return client.commitTx(tx) == nil
 }
 
 /*
   Rollback the transaction
 */
 func (client *DBClient) TxRollback(tx *db.Tx) {
+	client.rollbackTx(tx)
+}
+
+func (client *DBClient) commitTx(tx *db.Tx) error {
+	err := tx.Commit()
+	client.endTxSpan(tx, TxResultCommit, err)
+	if err != nil {
+		client.logError(MySQL+" tx commit failed", err)
+	}
+	return err
+}
+
+func (client *DBClient) rollbackTx(tx *db.Tx) error {
 	err := tx.Rollback()
+	client.endTxSpan(tx, TxResultRollback, nil)
 	if err != nil {
-		tsgutils.CheckAndPrintError(MySQL+" tx rollback failed", err)
+		client.logError(MySQL+" tx rollback failed", err)
 	}
+	return err
 }
 
 /*
   Get MySQL statement,transaction
 */
 func (client *DBClient) GetTxStmt(tx *db.Tx, sql string) (stmt *db.Stmt, err error) {
-	stmt, err = tx.Prepare(sql)
+	return client.GetTxStmtContext(context.Background(), tx, sql)
+}
+
+/*
+  Get MySQL statement,transaction,context
+*/
+func (client *DBClient) GetTxStmtContext(ctx context.Context, tx *db.Tx, sql string) (stmt *db.Stmt, err error) {
+	stmt, err = tx.PrepareContext(ctx, sql)
 	if err != nil {
-		PrintErrorSql(err, sql, nil)
+		client.logErrorSql(err, sql)
 		return nil, err
 	}
 	return stmt, nil
@@ -221,13 +595,24 @@ func (client *DBClient) GetTxStmt(tx *db.Tx, sql string) (stmt *db.Stmt, err err
   Get database table a row data,transaction
 */
 func (client *DBClient) TxQueryRow(tx *db.Tx, orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetTxStmt(tx, sql)
-	if stmt == nil || err != nil {
-		return nil, err
-	}
-	row, err = client.forkQuery(stmt, orm, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
+	return client.TxQueryRowContext(context.Background(), tx, orm, sql, args...)
+}
+
+/*
+  Get database table a row data,transaction,context
+*/
+func (client *DBClient) TxQueryRowContext(ctx context.Context, tx *db.Tx, orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
+	event := newQueryEvent(OpQueryRow, tx, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, false)
+		if stmt == nil || err != nil {
+			return err
+		}
+		row, err = client.forkQuery(ctx, stmt, orm, event.Sql, event.Args...)
+		event.rowErr = rowErr(row, err)
+		release(event.rowErr)
+		return err
+	})
 	return row, err
 }
 
@@ -235,44 +620,65 @@ func (client *DBClient) TxQueryRow(tx *db.Tx, orm ORMBase, sql string, args ...i
   Get database table multiple rows data,transaction
 */
 func (client *DBClient) TxQueryList(tx *db.Tx, orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetTxStmt(tx, sql)
-	if stmt == nil || err != nil {
-		return nil, err
-	}
-	rows, err = client.forkQueryList(stmt, orm, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
+	return client.TxQueryListContext(context.Background(), tx, orm, sql, args...)
+}
+
+/*
+  Get database table multiple rows data,transaction,context
+*/
+func (client *DBClient) TxQueryListContext(ctx context.Context, tx *db.Tx, orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
+	event := newQueryEvent(OpQueryList, tx, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, false)
+		if stmt == nil || err != nil {
+			return err
+		}
+		rows, err = client.forkQueryList(ctx, stmt, orm, event.Sql, event.Args...)
+		release(err)
+		return err
+	})
 	return rows, err
 }
 
 /*
- Database aggregate function, eg: SUM(*),COUNT(*) etc,transaction
+ Database aggregate function, eg: SUM(*),COUNT(*) etc,transaction, no rows: sql.ErrNoRows, NULL: ErrNullAggregate
 */
 func (client *DBClient) TxQueryAggregate(tx *db.Tx, sql string, args ...interface{}) (aggregate int64, err error) {
-	row, err := client.TxQueryRow(tx, nil, sql, args...)
+	return client.TxQueryAggregateContext(context.Background(), tx, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(*),COUNT(*) etc,transaction,context
+*/
+func (client *DBClient) TxQueryAggregateContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (aggregate int64, err error) {
+	value, err := client.queryAggregate(ctx, tx, sql, args)
 	if err != nil {
 		return 0, err
 	}
-	var result int64
-	err = row.Scan(&result)
-	if err != nil {
-		return 0, nil
-	}
-	return result, nil
+	return value.Int64()
 }
 
 /*
   Modify database table info or data,transaction
 */
 func (client *DBClient) TxExec(tx *db.Tx, sql string, args ...interface{}) (result int64, err error) {
-	start := tsgutils.Millisecond()
-	stmt, err := client.GetTxStmt(tx, sql)
-	if stmt == nil || err != nil {
-		return 0, err
-	}
-	result, err = client.forkExec(stmt, sql, args...)
-	client.slowSql(tsgutils.Millisecond()-start, sql, args...)
-	defer client.CloseStmt(stmt)
+	return client.TxExecContext(context.Background(), tx, sql, args...)
+}
+
+/*
+  Modify database table info or data,transaction,context
+*/
+func (client *DBClient) TxExecContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) (result int64, err error) {
+	event := newQueryEvent(OpExec, tx, sql, args)
+	err = client.runQuery(ctx, event, func(ctx context.Context) error {
+		stmt, release, err := client.getRunner(ctx, event, true)
+		if stmt == nil || err != nil {
+			return err
+		}
+		result, event.RowsAffected, err = client.forkExec(ctx, stmt, event.Sql, event.Args...)
+		release(err)
+		return err
+	})
 	return result, err
 }
 
@@ -281,7 +687,9 @@ func (client *DBClient) TxExec(tx *db.Tx, sql string, args ...interface{}) (resu
 */
 func (client *DBClient) QueryMetaData(tabName string) *db.Rows {
 	rows, err := client.Db.Query("SELECT * FROM " + tabName + " WHERE 1=1 LIMIT 1;")
-	tsgutils.CheckAndPrintError("Query '"+tabName+"' table meta data failed", err)
+	if err != nil {
+		client.logError("Query '"+tabName+"' table meta data failed", err)
+	}
 	return rows
 }
 
@@ -293,26 +701,28 @@ func (client *DBClient) QueryDBInfo() *db.Rows {
 		"FROM information_schema.TABLES tab,INFORMATION_SCHEMA.Columns col " +
 		"WHERE col.TABLE_NAME=tab.TABLE_NAME AND tab.`TABLE_SCHEMA` = ?"
 	rows, err := client.Db.Query(sql, client.Config.DbName)
-	tsgutils.CheckAndPrintError("Query '"+client.Config.DbName+"' db info failed", err)
+	if err != nil {
+		client.logError("Query '"+client.Config.DbName+"' db info failed", err)
+	}
 	return rows
 }
 
-func (client *DBClient) forkQuery(stmt *db.Stmt, orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
-	row = stmt.QueryRow(args...)
+func (client *DBClient) forkQuery(ctx context.Context, stmt stmtRunner, orm ORMBase, sql string, args ...interface{}) (row *db.Row, err error) {
+	row = stmt.QueryRowContext(ctx, args...)
 	if orm != nil {
 		err = orm.RowToStruct(row)
 		if err != nil {
-			PrintErrorSql(err, sql, args...)
+			client.logErrorSql(err, sql, args...)
 			return nil, err
 		}
 	}
 	return row, nil
 }
 
-func (client *DBClient) forkQueryList(stmt *db.Stmt, orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
-	rows, err = stmt.Query(args...)
+func (client *DBClient) forkQueryList(ctx context.Context, stmt stmtRunner, orm ORMBase, sql string, args ...interface{}) (rows *db.Rows, err error) {
+	rows, err = stmt.QueryContext(ctx, args...)
 	if err != nil {
-		PrintErrorSql(err, sql, args...)
+		client.logErrorSql(err, sql, args...)
 		return nil, err
 	}
 	if orm != nil {
@@ -325,28 +735,48 @@ func (client *DBClient) forkQueryList(stmt *db.Stmt, orm ORMBase, sql string, ar
 
 }
 
-func (client *DBClient) forkExec(stmt *db.Stmt, sql string, args ...interface{}) (result int64, err error) {
+/*
+  Exec the statement, result: the last insert id of INSERT, otherwise the rows affected
+*/
+func (client *DBClient) forkExec(ctx context.Context, stmt stmtRunner, sql string, args ...interface{}) (result, rowsAffected int64, err error) {
 	var results db.Result
-	results, err = stmt.Exec(args...)
+	results, err = stmt.ExecContext(ctx, args...)
 	if err != nil {
-		PrintErrorSql(err, sql, args...)
-		return 0, err
+		client.logErrorSql(err, sql, args...)
+		return 0, -1, err
 	}
-	var intResult int64
-	if tsgutils.NewString(sql).ContainsIgnoreCase("INSERT") {
-		intResult, err = results.LastInsertId()
-	} else {
-		intResult, err = results.RowsAffected()
+	rowsAffected, err = results.RowsAffected()
+	if err != nil {
+		client.logErrorSql(err, sql, args...)
+		return 0, -1, err
 	}
+	if !tsgutils.NewString(sql).ContainsIgnoreCase("INSERT") {
+		return rowsAffected, rowsAffected, nil
+	}
+	result, err = results.LastInsertId()
 	if err != nil {
-		PrintErrorSql(err, sql, args...)
-		return 0, err
+		client.logErrorSql(err, sql, args...)
+		return 0, rowsAffected, err
 	}
-	return intResult, nil
+	return result, rowsAffected, nil
 }
 
-func (client *DBClient) slowSql(consume int64, sql string, args ...interface{}) {
-	if consume > SlowSqlTimeoutMillisecond {
-		PrintSlowSql(client.Config.DbHost, client.Config.DbName, consume, sql, args...)
+func (client *DBClient) slowSql(ctx context.Context, consume int64, sql string, args ...interface{}) {
+	if ctx.Err() != nil {
+		client.log(LevelWarn, "canceled sql", sqlFields(client.Redactor, client.Config.DbHost, client.Config.DbName, consume, ctx.Err(), sql, args...)...)
+		return
+	}
+	threshold := client.Config.getSlowSqlThreshold(ctx)
+	if threshold < 0 || consume <= threshold {
+		return
+	}
+	logged, suppressed := client.sampleSlowSql(sql)
+	if !logged {
+		return
+	}
+	fields := sqlFields(client.Redactor, client.Config.DbHost, client.Config.DbName, consume, nil, sql, args...)
+	if suppressed > 0 {
+		fields = append(fields, LogField{LogKeySuppressed, suppressed})
 	}
+	client.log(LevelWarn, "slow sql", fields...)
 }
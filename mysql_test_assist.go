@@ -8,11 +8,12 @@ package tsgmysqlutils
 */
 
 import (
-	"time"
-	"errors"
-	"reflect"
+	"context"
 	db "database/sql"
+	"errors"
 	"github.com/timespacegroup/go-utils"
+	"reflect"
+	"time"
 )
 
 func TestDbClient() *DBClient {
@@ -88,7 +89,7 @@ func (weTestTab1 *WeTestTab1) RowsToStruct(rows *db.Rows) error {
 	return nil
 }
 
-func (weTestTab1 *WeTestTab1) Insert(client *DBClient, idSet bool) (int64, error) {
+func (weTestTab1 *WeTestTab1) Insert(client Querier, idSet bool) (int64, error) {
 	structParam := *weTestTab1
 	sql := tsgutils.NewStringBuilder()
 	qSql := tsgutils.NewStringBuilder()
@@ -111,11 +112,10 @@ func (weTestTab1 *WeTestTab1) Insert(client *DBClient, idSet bool) (int64, error
 	sql.RemoveLast()
 	qSql.RemoveLast()
 	sql.Append(") VALUES (").Append(qSql.ToString()).Append(");")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), params.ToInterfaces()...)
 }
 
-func (weTestTab1 *WeTestTab1) UpdateWeTestTab1ById(client *DBClient) (int64, error) {
+func (weTestTab1 *WeTestTab1) UpdateWeTestTab1ById(client Querier) (int64, error) {
 	structParam := *weTestTab1
 	sql := tsgutils.NewStringBuilder()
 	params := tsgutils.NewInterfaceBuilder()
@@ -138,21 +138,19 @@ func (weTestTab1 *WeTestTab1) UpdateWeTestTab1ById(client *DBClient) (int64, err
 	sql.RemoveLast()
 	params.Append(id)
 	sql.Append(" WHERE id = ?;")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), params.ToInterfaces()...)
 }
 
-func (weTestTab1 *WeTestTab1) DeleteWeTestTab1ById(client *DBClient) (int64, error) {
+func (weTestTab1 *WeTestTab1) DeleteWeTestTab1ById(client Querier) (int64, error) {
 	structParam := weTestTab1
 	sql := tsgutils.NewStringBuilder()
 	sql.Append("DELETE FROM ")
 	sql.Append("we_test_tab1")
 	sql.Append(" WHERE id = ?;")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), structParam.Id)
 }
 
-func (weTestTab1 *WeTestTab1) BatchInsert(client *DBClient, idSet, returnIds bool) ([]int64, error) {
+func (weTestTab1 *WeTestTab1) BatchInsert(client Querier, idSet, returnIds bool) ([]int64, error) {
 	structParam := *weTestTab1
 	list := structParam.WeTestTab1s
 	var result []int64
@@ -208,34 +206,31 @@ func (weTestTab1 *WeTestTab1) BatchInsert(client *DBClient, idSet, returnIds boo
 	} else {
 		oneSql := tsgutils.NewStringBuilder().Append(sql.ToString()).Append(oneQSql.ToString()).Append(";").ToString()
 		oneParams := tsgutils.NewInterfaceBuilder()
-		tx, err := client.TxBegin()
-		if err != nil {
-			return result, err
-		}
-		for m := range list {
-			oneParams.Clear()
-			item := list[m]
-			mItem := reflect.ValueOf(item)
-			for n := 0; n < fieldsNum; n++ {
-				nCol := ks.Field(n).Tag.Get("column")
-				if nCol == "id" && !idSet {
-					continue
+		err := client.WithTx(context.Background(), nil, func(tx *Tx) error {
+			for m := range list {
+				oneParams.Clear()
+				item := list[m]
+				mItem := reflect.ValueOf(item)
+				for n := 0; n < fieldsNum; n++ {
+					nCol := ks.Field(n).Tag.Get("column")
+					if nCol == "id" && !idSet {
+						continue
+					}
+					oneParams.Append(mItem.Field(n).Interface())
 				}
-				oneParams.Append(mItem.Field(n).Interface())
-			}
-			id, err := client.TxExec(tx, oneSql, oneParams.ToInterfaces()...)
-			if err != nil {
-				client.TxRollback(tx)
-				var resultTxRollback []int64
-				return resultTxRollback, err
+				id, err := tx.Exec(oneSql, oneParams.ToInterfaces()...)
+				if err != nil {
+					return err
+				}
+				result = append(result, id)
 			}
-			result = append(result, id)
-		}
-		if !client.TxCommit(tx) {
-			return result, errors.New("batch insert (returnIds=true) tx commit failed")
+			return nil
+		})
+		if err != nil {
+			var resultTxRollback []int64
+			return resultTxRollback, err
 		}
 	}
-	defer client.CloseConn()
 	return result, nil
 }
 
@@ -306,7 +301,7 @@ func (weTestTab2 *WeTestTab2) RowsToStruct(rows *db.Rows) error {
 	return nil
 }
 
-func (weTestTab2 *WeTestTab2) Insert(client *DBClient, idSet bool) (int64, error) {
+func (weTestTab2 *WeTestTab2) Insert(client Querier, idSet bool) (int64, error) {
 	structParam := *weTestTab2
 	sql := tsgutils.NewStringBuilder()
 	qSql := tsgutils.NewStringBuilder()
@@ -329,11 +324,10 @@ func (weTestTab2 *WeTestTab2) Insert(client *DBClient, idSet bool) (int64, error
 	sql.RemoveLast()
 	qSql.RemoveLast()
 	sql.Append(") VALUES (").Append(qSql.ToString()).Append(");")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), params.ToInterfaces()...)
 }
 
-func (weTestTab2 *WeTestTab2) UpdateWeTestTab2ById(client *DBClient) (int64, error) {
+func (weTestTab2 *WeTestTab2) UpdateWeTestTab2ById(client Querier) (int64, error) {
 	structParam := *weTestTab2
 	sql := tsgutils.NewStringBuilder()
 	params := tsgutils.NewInterfaceBuilder()
@@ -356,21 +350,19 @@ func (weTestTab2 *WeTestTab2) UpdateWeTestTab2ById(client *DBClient) (int64, err
 	sql.RemoveLast()
 	params.Append(id)
 	sql.Append(" WHERE id = ?;")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), params.ToInterfaces()...)
 }
 
-func (weTestTab2 *WeTestTab2) DeleteWeTestTab2ById(client *DBClient) (int64, error) {
+func (weTestTab2 *WeTestTab2) DeleteWeTestTab2ById(client Querier) (int64, error) {
 	structParam := weTestTab2
 	sql := tsgutils.NewStringBuilder()
 	sql.Append("DELETE FROM ")
 	sql.Append("we_test_tab2")
 	sql.Append(" WHERE id = ?;")
-	defer client.CloseConn()
 	return client.Exec(sql.ToString(), structParam.Id)
 }
 
-func (weTestTab2 *WeTestTab2) BatchInsert(client *DBClient, idSet, returnIds bool) ([]int64, error) {
+func (weTestTab2 *WeTestTab2) BatchInsert(client Querier, idSet, returnIds bool) ([]int64, error) {
 	structParam := *weTestTab2
 	list := structParam.WeTestTab2s
 	var result []int64
@@ -426,33 +418,30 @@ func (weTestTab2 *WeTestTab2) BatchInsert(client *DBClient, idSet, returnIds boo
 	} else {
 		oneSql := tsgutils.NewStringBuilder().Append(sql.ToString()).Append(oneQSql.ToString()).Append(";").ToString()
 		oneParams := tsgutils.NewInterfaceBuilder()
-		tx, err := client.TxBegin()
-		if err != nil {
-			return result, err
-		}
-		for m := range list {
-			oneParams.Clear()
-			item := list[m]
-			mItem := reflect.ValueOf(item)
-			for n := 0; n < fieldsNum; n++ {
-				nCol := ks.Field(n).Tag.Get("column")
-				if nCol == "id" && !idSet {
-					continue
+		err := client.WithTx(context.Background(), nil, func(tx *Tx) error {
+			for m := range list {
+				oneParams.Clear()
+				item := list[m]
+				mItem := reflect.ValueOf(item)
+				for n := 0; n < fieldsNum; n++ {
+					nCol := ks.Field(n).Tag.Get("column")
+					if nCol == "id" && !idSet {
+						continue
+					}
+					oneParams.Append(mItem.Field(n).Interface())
 				}
-				oneParams.Append(mItem.Field(n).Interface())
-			}
-			id, err := client.TxExec(tx, oneSql, oneParams.ToInterfaces()...)
-			if err != nil {
-				client.TxRollback(tx)
-				var resultTxRollback []int64
-				return resultTxRollback, err
+				id, err := tx.Exec(oneSql, oneParams.ToInterfaces()...)
+				if err != nil {
+					return err
+				}
+				result = append(result, id)
 			}
-			result = append(result, id)
-		}
-		if !client.TxCommit(tx) {
-			return result, errors.New("batch insert (returnIds=true) tx commit failed")
+			return nil
+		})
+		if err != nil {
+			var resultTxRollback []int64
+			return resultTxRollback, err
 		}
 	}
-	defer client.CloseConn()
 	return result, nil
 }
@@ -0,0 +1,251 @@
+package tsgmysqlutils
+
+/*
+ Named placeholders and slice args of the statements
+  Usage:
+	// named: a map or a struct by the "column" tags
+	client.QueryList(nil, "SELECT * FROM we_test_tab1 WHERE gender = :gender AND name LIKE :name",
+		map[string]interface{}{"gender": 2, "name": "t%"})
+	client.Exec("UPDATE we_test_tab1 SET name = :name WHERE id = :id", tab1)
+
+	// slice: IN (?) => IN (?, ?, ?)
+	client.QueryList(nil, "SELECT * FROM we_test_tab1 WHERE id IN (?) AND is_deleted = ?", []int64{1, 2, 3}, 0)
+	client.QueryList(nil, "SELECT * FROM we_test_tab1 WHERE id IN (:ids)", map[string]interface{}{"ids": ids})
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/*
+  Bind the named placeholders and expand the slice args:
+  named: the only arg is a map of the string keys or a struct (pointer), the sql has ":name" placeholders and no "?",
+  the struct fields are matched by the "column" tags, or the field names ignoring case,
+  the missing names and the unused map keys are errors;
+  slice: a slice arg (except []byte) of a "?" is expanded to "?, ?, ?", the empty slices are errors.
+  The other sqls and args are unchanged
+*/
+func BindArgs(sql string, args ...interface{}) (string, []interface{}, error) {
+	sql, args, _, err := bindArgs(sql, args)
+	return sql, args, err
+}
+
+/*
+  See BindArgs, expanded: a slice arg is expanded, the sql varies by the slice lengths
+*/
+func bindArgs(sql string, args []interface{}) (string, []interface{}, bool, error) {
+	if len(args) == 1 && isNamedArg(args[0]) && len(findPlaceholders(sql)) == 0 {
+		var err error
+		sql, args, err = bindNamed(sql, args[0])
+		if err != nil {
+			return sql, args, false, err
+		}
+	}
+	return expandSliceArgs(sql, args)
+}
+
+type boundSqlKey struct{}
+
+/*
+  The context of the sql whose args are bound, eg: the sqls of the builders, the args are not bound again,
+  a slice arg is passed to the driver unchanged
+*/
+func contextWithBoundSql(ctx context.Context, sql string) context.Context {
+	return context.WithValue(ctx, boundSqlKey{}, sql)
+}
+
+func isBoundSql(ctx context.Context, sql string) bool {
+	bound, ok := ctx.Value(boundSqlKey{}).(string)
+	return ok && bound == sql
+}
+
+/*
+  A map of the string keys, or a struct (pointer) not a driver value
+*/
+func isNamedArg(arg interface{}) bool {
+	if _, ok := arg.(driver.Valuer); ok || arg == nil {
+		return false
+	}
+	value := reflect.ValueOf(arg)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map:
+		return value.Type().Key().Kind() == reflect.String
+	case reflect.Struct:
+		return !isScalarType(value.Type())
+	}
+	return false
+}
+
+func bindNamed(sql string, arg interface{}) (string, []interface{}, error) {
+	positional, names := parseNamedSql(sql)
+	if len(names) == 0 {
+		return sql, []interface{}{arg}, nil
+	}
+	value := reflect.ValueOf(arg)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return sql, nil, fmt.Errorf("%s named arg is a nil %T", MySQL, arg)
+		}
+		value = value.Elem()
+	}
+	args := make([]interface{}, len(names))
+	if value.Kind() == reflect.Map {
+		used := make(map[string]bool)
+		for i, name := range names {
+			v := value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+			if !v.IsValid() {
+				return sql, nil, fmt.Errorf("%s named arg ':%s' is missing", MySQL, name)
+			}
+			args[i] = v.Interface()
+			used[name] = true
+		}
+		var unused []string
+		for _, key := range value.MapKeys() {
+			if !used[key.String()] {
+				unused = append(unused, key.String())
+			}
+		}
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return sql, nil, fmt.Errorf("%s named args are unused: %s", MySQL, strings.Join(unused, ", "))
+		}
+		return positional, args, nil
+	}
+	plan := getStructPlan(value.Type())
+	for i, name := range names {
+		index := plan.fieldIndex(name)
+		if index == nil {
+			return sql, nil, fmt.Errorf("%s named arg ':%s' has no field in %s", MySQL, name, value.Type())
+		}
+		args[i] = value.FieldByIndex(index).Interface()
+	}
+	return positional, args, nil
+}
+
+/*
+  Replace the ":name" placeholders outside the quoted strings, identifiers and comments by "?",
+  ":=" and "::" are kept
+*/
+func parseNamedSql(sql string) (string, []string) {
+	var names []string
+	builder := strings.Builder{}
+	builder.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if end := sqlTextEnd(sql, i); end >= 0 {
+			builder.WriteString(sql[i : end+1])
+			i = end
+			continue
+		}
+		switch {
+		case c == ':' && i+1 < len(sql) && isNameStart(sql[i+1]) && (i == 0 || sql[i-1] != ':'):
+			end := i + 2
+			for end < len(sql) && isIdentByte(sql[end]) {
+				end++
+			}
+			names = append(names, sql[i+1:end])
+			builder.WriteByte('?')
+			i = end - 1
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String(), names
+}
+
+/*
+  Get the last index of the quoted string, the quoted identifier or the comment at i, -1 if none,
+  the unterminated ones end at the end of the sql, the placeholders in them are not bound
+*/
+func sqlTextEnd(sql string, i int) int {
+	c := sql[i]
+	switch {
+	case c == '\'' || c == '"' || c == '`':
+		end := i + 1
+		for ; end < len(sql) && sql[end] != c; end++ {
+			if sql[end] == '\\' && c != '`' {
+				end++
+			}
+		}
+		return min(end, len(sql)-1)
+	case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")):
+		if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+			return i + end - 1
+		}
+		return len(sql) - 1
+	case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+		if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+			return i + end + 3
+		}
+		return len(sql) - 1
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+/*
+  Expand the slice args of the "?" placeholders
+*/
+func expandSliceArgs(sql string, args []interface{}) (string, []interface{}, bool, error) {
+	expand := false
+	for _, arg := range args {
+		expand = expand || isSliceArg(arg)
+	}
+	if !expand {
+		return sql, args, false, nil
+	}
+	placeholders := findPlaceholders(sql)
+	if len(placeholders) != len(args) {
+		return sql, args, false, fmt.Errorf("%s %d placeholders of %d args can not expand the slice args", MySQL, len(placeholders), len(args))
+	}
+	builder := strings.Builder{}
+	expanded := make([]interface{}, 0, len(args))
+	last := 0
+	for i, arg := range args {
+		if !isSliceArg(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+		value := reflect.ValueOf(arg)
+		if value.Len() == 0 {
+			return sql, args, false, fmt.Errorf("%s slice arg %d is empty", MySQL, i)
+		}
+		builder.WriteString(sql[last:placeholders[i]])
+		for j := 0; j < value.Len(); j++ {
+			if j > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteByte('?')
+			expanded = append(expanded, value.Index(j).Interface())
+		}
+		last = placeholders[i] + 1
+	}
+	builder.WriteString(sql[last:])
+	return builder.String(), expanded, true, nil
+}
+
+/*
+  A slice or array arg, except []byte and the driver values
+*/
+func isSliceArg(arg interface{}) bool {
+	if _, ok := arg.(driver.Valuer); ok || arg == nil {
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
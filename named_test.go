@@ -0,0 +1,78 @@
+package tsgmysqlutils
+
+/*
+ Named placeholders test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindArgs(t *testing.T) {
+	type user struct {
+		Id     int64 `column:"user_id"`
+		Name   string
+		Gender int64
+	}
+	cases := []struct {
+		sql      string
+		args     []interface{}
+		expected string
+	}{
+		{"SELECT * FROM user WHERE id = :user_id AND name = :name AND note = ':skip' -- :skip\n AND `:skip` = :user_id",
+			[]interface{}{map[string]interface{}{"user_id": 1, "name": "tony"}},
+			"SELECT * FROM user WHERE id = ? AND name = ? AND note = ':skip' -- :skip\n AND `:skip` = ? [1 tony 1]"},
+		{"UPDATE user SET name = :name, @x := 1 WHERE id = :user_id", []interface{}{&user{Id: 7, Name: "tina"}},
+			"UPDATE user SET name = ?, @x := 1 WHERE id = ? [tina 7]"},
+		{"SELECT * FROM user WHERE id IN (?) AND gender = ? AND tag IN (?)", []interface{}{[]int64{1, 2, 3}, 2, [2]string{"a", "b"}},
+			"SELECT * FROM user WHERE id IN (?, ?, ?) AND gender = ? AND tag IN (?, ?) [1 2 3 2 a b]"},
+		{"SELECT * FROM user WHERE id IN (:ids) AND gender = :gender", []interface{}{map[string]interface{}{"ids": []int{4, 5}, "gender": 1}},
+			"SELECT * FROM user WHERE id IN (?, ?) AND gender = ? [4 5 1]"},
+		{"UPDATE user SET avatar = ? WHERE id = ?", []interface{}{[]byte("png"), 1}, "UPDATE user SET avatar = ? WHERE id = ? [[112 110 103] 1]"},
+		{"SELECT * FROM user WHERE created_time > ?", []interface{}{time.Unix(0, 0).UTC()}, "SELECT * FROM user WHERE created_time > ? [1970-01-01 00:00:00 +0000 UTC]"},
+		{"SELECT * FROM user WHERE password = ?", []interface{}{Secret("123456")}, "SELECT * FROM user WHERE password = ? [******]"},
+		// the "?" in the comments are not placeholders
+		{"SELECT * FROM user /* id IN (?) */ WHERE id IN (?) # why ?\n AND gender = ? -- ?", []interface{}{[]int64{1, 2}, 2},
+			"SELECT * FROM user /* id IN (?) */ WHERE id IN (?, ?) # why ?\n AND gender = ? -- ? [1 2 2]"},
+		{"SELECT * FROM user WHERE id = :id -- why ?", []interface{}{map[string]interface{}{"id": 1}}, "SELECT * FROM user WHERE id = ? -- why ? [1]"},
+	}
+	for _, c := range cases {
+		sql, args, err := BindArgs(c.sql, c.args...)
+		if bound := fmt.Sprint(sql, " ", args); err != nil || bound != c.expected {
+			t.Error("Bind args:", bound, err)
+		}
+	}
+
+	failures := []struct {
+		sql   string
+		args  []interface{}
+		error string
+	}{
+		{"SELECT * FROM user WHERE id = :id AND name = :name", []interface{}{map[string]interface{}{"id": 1}}, "':name' is missing"},
+		{"SELECT * FROM user WHERE id = :id", []interface{}{map[string]interface{}{"id": 1, "name": "tony", "age": 18}}, "unused: age, name"},
+		{"SELECT * FROM user WHERE id = :id", []interface{}{user{}}, "':id' has no field"},
+		{"SELECT * FROM user WHERE id IN (?)", []interface{}{[]int64{}}, "slice arg 0 is empty"},
+		{"SELECT * FROM user WHERE id IN (?) AND gender = ?", []interface{}{[]int64{1}}, "1 args"},
+	}
+	for _, f := range failures {
+		if _, _, err := BindArgs(f.sql, f.args...); err == nil || !strings.Contains(err.Error(), f.error) {
+			t.Error("Bind args failure:", f.sql, err)
+		}
+	}
+
+	client, fake := newFakeClient(t, nil)
+	_, err := client.Exec("DELETE FROM user WHERE id IN (:ids) AND gender = :gender", map[string]interface{}{"ids": []int64{1, 2}, "gender": 2})
+	log := fake.getLog()
+	if err != nil || len(log) != 1 || log[0] != "exec DELETE FROM user WHERE id IN (?, ?) AND gender = ? [1 2 2]" {
+		t.Error("Exec named args:", log, err)
+	}
+	if _, err = client.Exec("DELETE FROM user WHERE id = :id", map[string]interface{}{}); err == nil || len(fake.getLog()) != 1 {
+		t.Error("Exec missing named arg:", err)
+	}
+}
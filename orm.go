@@ -23,6 +23,9 @@ import (
 	tabNames := []string{"we_test_tab1", "we_test_tab2"}
 	orm.DefaultGenerator(tabNames)
 
+	The generated Insert, Update*ById, Delete*ById and BatchInsert take a Querier: a DBClient or a Tx,
+	they do not close the client, call client.CloseConn() when done.
+
    @author Tony Tian
    @date 2018-04-16
    @version 1.0.0
@@ -73,11 +76,12 @@ func (orm *ORMGenerator) ORMBuilder(tabNames []string) {
 func (orm *ORMGenerator) buildORMImport() {
 	importBuilder := tsgutils.NewStringBuilder()
 	importBuilder.Append("import (").Append("\n")
-	importBuilder.Append("\t").Append("\"time\"").Append("\n")
-	importBuilder.Append("\t").Append("\"errors\"").Append("\n")
-	importBuilder.Append("\t").Append("\"reflect\"").Append("\n")
+	importBuilder.Append("\t").Append("\"context\"").Append("\n")
 	importBuilder.Append("\t").Append("db \"database/sql\"").Append("\n")
+	importBuilder.Append("\t").Append("\"errors\"").Append("\n")
 	importBuilder.Append("\t").Append("\"github.com/timespacegroup/go-utils\"").Append("\n")
+	importBuilder.Append("\t").Append("\"reflect\"").Append("\n")
+	importBuilder.Append("\t").Append("\"time\"").Append("\n")
 	importBuilder.Append(")").Append("\n")
 	tsgutils.Stdout(importBuilder.ToString())
 }
@@ -178,7 +182,7 @@ func (orm *ORMGenerator) buildORMSqlInsert(tabName string) {
 	funcInsertBuilder := tsgutils.NewStringBuilder()
 	structName := getStructName(tabName)
 	aliasStructName := getAliasStructName(tabName)
-	funcInsertBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Insert(client *DBClient, idSet bool) (int64, error) {").Append("\n")
+	funcInsertBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Insert(client Querier, idSet bool) (int64, error) {").Append("\n")
 	funcInsertBuilder.Append("\t").Append("structParam := *").Append(aliasStructName).Append("\n")
 	funcInsertBuilder.Append("\t").Append("sql := tsgutils.NewStringBuilder()").Append("\n")
 	funcInsertBuilder.Append("\t").Append("qSql := tsgutils.NewStringBuilder()").Append("\n")
@@ -201,7 +205,6 @@ func (orm *ORMGenerator) buildORMSqlInsert(tabName string) {
 	funcInsertBuilder.Append("\t").Append("sql.RemoveLast()").Append("\n")
 	funcInsertBuilder.Append("\t").Append("qSql.RemoveLast()").Append("\n")
 	funcInsertBuilder.Append("\t").Append("sql.Append(\") VALUES (\").Append(qSql.ToString()).Append(\");\")").Append("\n")
-	funcInsertBuilder.Append("\t").Append("defer client.CloseConn()").Append("\n")
 	funcInsertBuilder.Append("\t").Append("return client.Exec(sql.ToString(), params.ToInterfaces()...)").Append("\n")
 	funcInsertBuilder.Append("}").Append("\n")
 	tsgutils.Stdout(funcInsertBuilder.ToString())
@@ -211,7 +214,7 @@ func (orm *ORMGenerator) buildORMSqlUpdate(tabName string) {
 	funcUpdateBuilder := tsgutils.NewStringBuilder()
 	structName := getStructName(tabName)
 	aliasStructName := getAliasStructName(tabName)
-	funcUpdateBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Update").Append(structName).Append("ById(client *DBClient) (int64, error) {").Append("\n")
+	funcUpdateBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Update").Append(structName).Append("ById(client Querier) (int64, error) {").Append("\n")
 	funcUpdateBuilder.Append("\t").Append("structParam := *").Append(aliasStructName).Append("\n")
 	funcUpdateBuilder.Append("\t").Append("sql := tsgutils.NewStringBuilder()").Append("\n")
 	funcUpdateBuilder.Append("\t").Append("params := tsgutils.NewInterfaceBuilder()").Append("\n")
@@ -234,7 +237,6 @@ func (orm *ORMGenerator) buildORMSqlUpdate(tabName string) {
 	funcUpdateBuilder.Append("\t").Append("sql.RemoveLast()").Append("\n")
 	funcUpdateBuilder.Append("\t").Append("params.Append(id)").Append("\n")
 	funcUpdateBuilder.Append("\t").Append("sql.Append(\" WHERE id = ?;\")").Append("\n")
-	funcUpdateBuilder.Append("\t").Append("defer client.CloseConn()").Append("\n")
 	funcUpdateBuilder.Append("\t").Append("return client.Exec(sql.ToString(), params.ToInterfaces()...)").Append("\n")
 	funcUpdateBuilder.Append("}").Append("\n")
 	tsgutils.Stdout(funcUpdateBuilder.ToString())
@@ -244,13 +246,12 @@ func (orm *ORMGenerator) buildORMSqlDelete(tabName string) {
 	funcDeleteBuilder := tsgutils.NewStringBuilder()
 	structName := getStructName(tabName)
 	aliasStructName := getAliasStructName(tabName)
-	funcDeleteBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Delete").Append(structName).Append("ById(client *DBClient) (int64, error) {").Append("\n")
+	funcDeleteBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") Delete").Append(structName).Append("ById(client Querier) (int64, error) {").Append("\n")
 	funcDeleteBuilder.Append("\t").Append("structParam := ").Append(aliasStructName).Append("\n")
 	funcDeleteBuilder.Append("\t").Append("sql := tsgutils.NewStringBuilder()").Append("\n")
 	funcDeleteBuilder.Append("\t").Append("sql.Append(\"DELETE FROM \")").Append("\n")
 	funcDeleteBuilder.Append("\t").Append("sql.Append(\"").Append(tabName).Append("\")\n")
 	funcDeleteBuilder.Append("\t").Append("sql.Append(\" WHERE id = ?;\")").Append("\n")
-	funcDeleteBuilder.Append("\t").Append("defer client.CloseConn()").Append("\n")
 	funcDeleteBuilder.Append("\t").Append("return client.Exec(sql.ToString(), structParam.Id)").Append("\n")
 	funcDeleteBuilder.Append("}").Append("\n")
 	tsgutils.Stdout(funcDeleteBuilder.ToString())
@@ -261,7 +262,7 @@ func (orm *ORMGenerator) buildORMSqlBatchInsert(tabName string) {
 	structName := getStructName(tabName)
 	aliasStructName := getAliasStructName(tabName)
 	structNames := getStructNames(tabName)
-	funcBatchInsertBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") BatchInsert(client *DBClient, idSet, returnIds bool) ([]int64, error) {").Append("\n")
+	funcBatchInsertBuilder.Append("func (").Append(aliasStructName).Append(" *").Append(structName).Append(") BatchInsert(client Querier, idSet, returnIds bool) ([]int64, error) {").Append("\n")
 	funcBatchInsertBuilder.Append("\t").Append("structParam := *").Append(aliasStructName).Append("\n")
 	funcBatchInsertBuilder.Append("\t").Append("list := structParam.").Append(structNames).Append("\n")
 	funcBatchInsertBuilder.Append("\t").Append("var result []int64").Append("\n")
@@ -317,34 +318,31 @@ func (orm *ORMGenerator) buildORMSqlBatchInsert(tabName string) {
 	funcBatchInsertBuilder.Append("\t").Append("} else {").Append("\n")
 	funcBatchInsertBuilder.Append("\t\t").Append("oneSql := tsgutils.NewStringBuilder().Append(sql.ToString()).Append(oneQSql.ToString()).Append(\";\").ToString()").Append("\n")
 	funcBatchInsertBuilder.Append("\t\t").Append("oneParams := tsgutils.NewInterfaceBuilder()").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("tx, err := client.TxBegin()").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("if err != nil {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("return result, err").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("for m := range list {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("oneParams.Clear()").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("item := list[m]").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("mItem := reflect.ValueOf(item)").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("for n := 0; n < fieldsNum; n++ {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("nCol := ks.Field(n).Tag.Get(\"column\")").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("if nCol == \"id\" && !idSet {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("continue").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t").Append("err := client.WithTx(context.Background(), nil, func(tx *Tx) error {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t").Append("for m := range list {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("oneParams.Clear()").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("item := list[m]").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("mItem := reflect.ValueOf(item)").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("for n := 0; n < fieldsNum; n++ {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("nCol := ks.Field(n).Tag.Get(\"column\")").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("if nCol == \"id\" && !idSet {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t\t").Append("continue").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("}").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("oneParams.Append(mItem.Field(n).Interface())").Append("\n")
 	funcBatchInsertBuilder.Append("\t\t\t\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("oneParams.Append(mItem.Field(n).Interface())").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("id, err := client.TxExec(tx, oneSql, oneParams.ToInterfaces()...)").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("if err != nil {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("client.TxRollback(tx)").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("var resultTxRollback []int64").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t\t").Append("return resultTxRollback, err").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("id, err := tx.Exec(oneSql, oneParams.ToInterfaces()...)").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("if err != nil {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t\t").Append("return err").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("}").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t\t").Append("result = append(result, id)").Append("\n")
 	funcBatchInsertBuilder.Append("\t\t\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("result = append(result, id)").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t").Append("if !client.TxCommit(tx) {").Append("\n")
-	funcBatchInsertBuilder.Append("\t\t\t").Append("return result, errors.New(\"batch insert (returnIds=true) tx commit failed\")").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t").Append("return nil").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t").Append("})").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t").Append("if err != nil {").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t").Append("var resultTxRollback []int64").Append("\n")
+	funcBatchInsertBuilder.Append("\t\t\t").Append("return resultTxRollback, err").Append("\n")
 	funcBatchInsertBuilder.Append("\t\t").Append("}").Append("\n")
 	funcBatchInsertBuilder.Append("\t").Append("}").Append("\n")
-	funcBatchInsertBuilder.Append("\t").Append("defer client.CloseConn()").Append("\n")
 	funcBatchInsertBuilder.Append("\t").Append("return result, nil").Append("\n")
 	funcBatchInsertBuilder.Append("}").Append("\n")
 	tsgutils.Stdout(funcBatchInsertBuilder.ToString())
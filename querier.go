@@ -0,0 +1,144 @@
+package tsgmysqlutils
+
+/*
+ The queries of a client or a transaction, the repositories run unchanged inside or outside a transaction
+  Usage:
+	func CreateUser(q tsgmysqlutils.Querier, user *User) (int64, error) {
+		return q.Exec("INSERT INTO user (name) VALUES (?)", user.Name)
+	}
+
+	id, err := CreateUser(client, user)
+	err := client.WithTx(ctx, nil, func(tx *tsgmysqlutils.Tx) error {
+		id, err := CreateUser(tx, user)
+		...
+	})
+
+	// the generic functions take a Querier too
+	users, err := tsgmysqlutils.QueryAll[User](tx, "SELECT * FROM user WHERE gender = ?", 2)
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"fmt"
+)
+
+/*
+  The queries of a DBClient or a Tx
+*/
+type Querier interface {
+	QueryRow(orm ORMBase, sql string, args ...interface{}) (*db.Row, error)
+	QueryRowContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (*db.Row, error)
+	QueryList(orm ORMBase, sql string, args ...interface{}) (*db.Rows, error)
+	QueryListContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (*db.Rows, error)
+	QueryAggregate(sql string, args ...interface{}) (int64, error)
+	QueryAggregateContext(ctx context.Context, sql string, args ...interface{}) (int64, error)
+	Exec(sql string, args ...interface{}) (int64, error)
+	ExecContext(ctx context.Context, sql string, args ...interface{}) (int64, error)
+	// DBClient: a transaction, Tx: a nested transaction
+	WithTx(ctx context.Context, opts *TxOptions, fn func(tx *Tx) error) error
+}
+
+var (
+	_ Querier = (*DBClient)(nil)
+	_ Querier = (*Tx)(nil)
+)
+
+/*
+  Get the client and the transaction of the querier, tx: if nil, not in a transaction,
+  sql.ErrTxDone if the Tx is committed or rolled back
+*/
+func querierClient(querier Querier) (client *DBClient, tx *db.Tx, err error) {
+	switch querier := querier.(type) {
+	case *DBClient:
+		return querier, nil, nil
+	case *Tx:
+		if querier.isDone() {
+			return nil, nil, db.ErrTxDone
+		}
+		return querier.client, querier.Tx, nil
+	}
+	return nil, nil, fmt.Errorf("%s unsupported querier %T", MySQL, querier)
+}
+
+/*
+  Get database table a row data,transaction
+*/
+func (tx *Tx) QueryRow(orm ORMBase, sql string, args ...interface{}) (*db.Row, error) {
+	return tx.QueryRowContext(context.Background(), orm, sql, args...)
+}
+
+/*
+  Get database table a row data,transaction,context
+*/
+func (tx *Tx) QueryRowContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (*db.Row, error) {
+	if tx.isDone() {
+		return nil, db.ErrTxDone
+	}
+	return tx.client.TxQueryRowContext(ctx, tx.Tx, orm, sql, args...)
+}
+
+/*
+  Get database table multiple rows data,transaction
+*/
+func (tx *Tx) QueryList(orm ORMBase, sql string, args ...interface{}) (*db.Rows, error) {
+	return tx.QueryListContext(context.Background(), orm, sql, args...)
+}
+
+/*
+  Get database table multiple rows data,transaction,context
+*/
+func (tx *Tx) QueryListContext(ctx context.Context, orm ORMBase, sql string, args ...interface{}) (*db.Rows, error) {
+	if tx.isDone() {
+		return nil, db.ErrTxDone
+	}
+	return tx.client.TxQueryListContext(ctx, tx.Tx, orm, sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(*),COUNT(*) etc,transaction
+*/
+func (tx *Tx) QueryAggregate(sql string, args ...interface{}) (int64, error) {
+	return tx.QueryAggregateContext(context.Background(), sql, args...)
+}
+
+/*
+ Database aggregate function, eg: SUM(*),COUNT(*) etc,transaction,context
+*/
+func (tx *Tx) QueryAggregateContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	if tx.isDone() {
+		return 0, db.ErrTxDone
+	}
+	return tx.client.TxQueryAggregateContext(ctx, tx.Tx, sql, args...)
+}
+
+/*
+  Modify database table info or data,transaction
+*/
+func (tx *Tx) Exec(sql string, args ...interface{}) (int64, error) {
+	return tx.ExecContext(context.Background(), sql, args...)
+}
+
+/*
+  Modify database table info or data,transaction,context
+*/
+func (tx *Tx) ExecContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	if tx.isDone() {
+		return 0, db.ErrTxDone
+	}
+	return tx.client.TxExecContext(ctx, tx.Tx, sql, args...)
+}
+
+/*
+  Run fn in a nested transaction of the savepoint, see DBClient.WithTx
+*/
+func (tx *Tx) WithTx(ctx context.Context, opts *TxOptions, fn func(tx *Tx) error) error {
+	if tx.isDone() {
+		return db.ErrTxDone
+	}
+	return tx.client.WithTx(ContextWithTx(ctx, tx), opts, fn)
+}
@@ -0,0 +1,79 @@
+package tsgmysqlutils
+
+/*
+ Querier test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestQuerier(t *testing.T) {
+	sql := "SELECT name FROM user"
+	client, fake := newFakeClient(t, map[string]fakeResult{sql: {columns: []string{"name"}, rows: [][]driver.Value{{[]byte("tony")}}}})
+	ctx := context.Background()
+	rename := func(q Querier, name string) (int64, error) {
+		return q.Exec("UPDATE user SET name = ? WHERE id = ?", name, 1)
+	}
+	if _, err := rename(client, "tony"); err != nil {
+		t.Error("Querier client:", err)
+	}
+	err := client.WithTx(ctx, nil, func(tx *Tx) error {
+		if _, err := rename(tx, "tina"); err != nil {
+			return err
+		}
+		names, err := QueryAll[string](tx, sql)
+		if err != nil || len(names) != 1 || names[0] != "tony" {
+			t.Error("Querier query all:", names, err)
+		}
+		return tx.WithTx(ctx, nil, func(tx *Tx) error {
+			_, err := rename(tx, "tom")
+			return err
+		})
+	})
+	expected := []string{
+		"exec UPDATE user SET name = ? WHERE id = ? [tony 1]",
+		"begin isolation=0 read_only=false",
+		"exec UPDATE user SET name = ? WHERE id = ? [tina 1]",
+		"query SELECT name FROM user []",
+		"exec SAVEPOINT tsg_savepoint_1 []",
+		"exec UPDATE user SET name = ? WHERE id = ? [tom 1]",
+		"exec RELEASE SAVEPOINT tsg_savepoint_1 []",
+		"commit",
+	}
+	if log := fake.getLog(); err != nil || !reflect.DeepEqual(log, expected) {
+		t.Error("Querier tx:", log, err)
+	}
+
+	tx, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal("Begin tx:", err)
+	}
+	tx.Rollback()
+	if err = tx.WithTx(ctx, nil, func(tx *Tx) error { return nil }); err != db.ErrTxDone {
+		t.Error("Querier done tx:", err)
+	}
+
+	// the released savepoint never joins the outer transaction
+	fake.log = nil
+	outer, _ := client.BeginTx(ctx, nil)
+	nested, _ := outer.Begin(ctx)
+	nested.Commit()
+	if _, err = rename(nested, "tom"); err != db.ErrTxDone {
+		t.Error("Querier released tx:", err)
+	}
+	outer.Commit()
+	if _, err = QueryAll[string](outer, sql); err != db.ErrTxDone {
+		t.Error("Querier committed tx:", err)
+	}
+	if log := fake.getLog(); len(log) != 4 {
+		t.Error("Querier done tx statements:", log)
+	}
+}
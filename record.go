@@ -0,0 +1,208 @@
+package tsgmysqlutils
+
+/*
+ Scan the ad-hoc queries of the unknown columns into the maps and records,
+ the []byte values are converted by the column database types:
+	TINYINT, SMALLINT, MEDIUMINT, INT, BIGINT, YEAR: int64, UNSIGNED: uint64
+	FLOAT, DOUBLE: float64
+	DATETIME, TIMESTAMP, DATE: time.Time in the client location
+	BLOB, BINARY, VARBINARY, BIT, GEOMETRY: []byte
+	DECIMAL (exact), TIME (may exceed 24 hours), CHAR, VARCHAR, TEXT, ENUM, SET, JSON etc: string
+  Usage:
+	maps, err := client.QueryMaps("SELECT * FROM we_test_tab1 WHERE gender = ?", 2)
+	name := maps[0]["name"].(string)
+
+	records, err := client.QueryRecords("SHOW PROCESSLIST")
+	for _, record := range records {
+		for i, column := range record.Columns {
+			fmt.Println(column.Name, column.DatabaseType, column.Nullable, record.Values[i])
+		}
+	}
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  The metadata of a result column
+*/
+type Column struct {
+	Name string
+	// the driver database type name, eg: "VARCHAR", "DECIMAL", "UNSIGNED BIGINT"
+	DatabaseType string
+	// false if NOT NULL or unknown
+	Nullable bool
+}
+
+/*
+  A row of the columns in order, the Columns are shared by the records of a query
+*/
+type Record struct {
+	Columns []Column
+	Values  []interface{}
+}
+
+/*
+  Get the value of the column name, the first column of the duplicate names
+*/
+func (record Record) Get(name string) (interface{}, bool) {
+	for i, column := range record.Columns {
+		if column.Name == name {
+			return record.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+/*
+  Get the map of the column names to the values, the last column of the duplicate names wins
+*/
+func (record Record) Map() map[string]interface{} {
+	values := make(map[string]interface{}, len(record.Columns))
+	for i, column := range record.Columns {
+		values[column.Name] = record.Values[i]
+	}
+	return values
+}
+
+/*
+  Get all rows as the maps of the column names to the values
+*/
+func (client *DBClient) QueryMaps(sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return client.QueryMapsContext(context.Background(), sql, args...)
+}
+
+/*
+  Get all rows as the maps of the column names to the values,context
+*/
+func (client *DBClient) QueryMapsContext(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	records, err := client.queryRecords(ctx, nil, sql, args)
+	return recordMaps(records), err
+}
+
+/*
+  Get all rows as the records with the column metadata
+*/
+func (client *DBClient) QueryRecords(sql string, args ...interface{}) ([]Record, error) {
+	return client.QueryRecordsContext(context.Background(), sql, args...)
+}
+
+/*
+  Get all rows as the records with the column metadata,context
+*/
+func (client *DBClient) QueryRecordsContext(ctx context.Context, sql string, args ...interface{}) ([]Record, error) {
+	return client.queryRecords(ctx, nil, sql, args)
+}
+
+/*
+  Get all rows as the maps of the column names to the values,transaction
+*/
+func (client *DBClient) TxQueryMaps(tx *db.Tx, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return client.TxQueryMapsContext(context.Background(), tx, sql, args...)
+}
+
+/*
+  Get all rows as the maps of the column names to the values,transaction,context
+*/
+func (client *DBClient) TxQueryMapsContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	records, err := client.queryRecords(ctx, tx, sql, args)
+	return recordMaps(records), err
+}
+
+/*
+  Get all rows as the records with the column metadata,transaction
+*/
+func (client *DBClient) TxQueryRecords(tx *db.Tx, sql string, args ...interface{}) ([]Record, error) {
+	return client.TxQueryRecordsContext(context.Background(), tx, sql, args...)
+}
+
+/*
+  Get all rows as the records with the column metadata,transaction,context
+*/
+func (client *DBClient) TxQueryRecordsContext(ctx context.Context, tx *db.Tx, sql string, args ...interface{}) ([]Record, error) {
+	return client.queryRecords(ctx, tx, sql, args)
+}
+
+func recordMaps(records []Record) []map[string]interface{} {
+	if records == nil {
+		return nil
+	}
+	maps := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		maps[i] = record.Map()
+	}
+	return maps
+}
+
+func (client *DBClient) queryRecords(ctx context.Context, tx *db.Tx, sql string, args []interface{}) ([]Record, error) {
+	rows, err := client.queryRows(ctx, tx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	columns := make([]Column, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i].Name = columnType.Name()
+		columns[i].DatabaseType = strings.ToUpper(columnType.DatabaseTypeName())
+		columns[i].Nullable, _ = columnType.Nullable()
+	}
+	loc := client.timeLocation()
+	var records []Record
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err = rows.Scan(targets...); err != nil {
+			return nil, client.scanError(err, sql, args...)
+		}
+		for i, value := range values {
+			if values[i], err = convertColumnValue(columns[i].DatabaseType, value, loc); err != nil {
+				return nil, client.scanError(err, sql, args...)
+			}
+		}
+		records = append(records, Record{Columns: columns, Values: values})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, client.scanError(err, sql, args...)
+	}
+	return records, nil
+}
+
+/*
+  Convert the []byte value by the database type, the other values are unchanged
+*/
+func convertColumnValue(databaseType string, value interface{}, loc *time.Location) (interface{}, error) {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return value, nil
+	}
+	text := string(bytes)
+	switch databaseType {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		return strconv.ParseInt(text, 10, 64)
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED INTEGER", "UNSIGNED BIGINT":
+		return strconv.ParseUint(text, 10, 64)
+	case "FLOAT", "DOUBLE", "REAL":
+		return strconv.ParseFloat(text, 64)
+	case "DATETIME", "TIMESTAMP", "DATE":
+		return parseTime(text, loc)
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BIT", "GEOMETRY":
+		return bytes, nil
+	}
+	return text, nil
+}
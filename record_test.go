@@ -0,0 +1,56 @@
+package tsgmysqlutils
+
+/*
+ Record query test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryRecords(t *testing.T) {
+	sql := "SELECT * FROM tab"
+	client, _ := newFakeClient(t, map[string]fakeResult{sql: {
+		columns:  []string{"id", "name", "amount", "score", "created_time", "avatar", "big", "note"},
+		types:    []string{"BIGINT", "VARCHAR", "DECIMAL", "DOUBLE", "DATETIME", "BLOB", "UNSIGNED BIGINT", "TEXT"},
+		nullable: []bool{false, false, true, true, false, true, false, true},
+		rows: [][]driver.Value{
+			{[]byte("1"), []byte("tony"), []byte("10.50"), []byte("1.5"), []byte("2018-04-19 13:20:09"), []byte{0xff}, []byte("18446744073709551615"), nil},
+			{int64(2), []byte("tina"), nil, float64(2.5), time.Date(2018, 4, 20, 8, 0, 0, 0, time.UTC), nil, []byte("0"), []byte("vip")},
+		},
+	}})
+	client.Config.IsLocalTime = true
+
+	records, err := client.QueryRecords(sql)
+	if err != nil || len(records) != 2 {
+		t.Fatal("Query records:", records, err)
+	}
+	columns := records[0].Columns
+	if len(columns) != 8 || columns[2] != (Column{"amount", "DECIMAL", true}) || columns[0].Nullable {
+		t.Error("Record columns:", columns)
+	}
+	expected := []interface{}{int64(1), "tony", "10.50", 1.5, time.Date(2018, 4, 19, 13, 20, 9, 0, time.Local), []byte{0xff}, uint64(18446744073709551615), nil}
+	if !reflect.DeepEqual(records[0].Values, expected) {
+		t.Error("Record values:", records[0].Values)
+	}
+	if note, ok := records[1].Get("note"); !ok || note != "vip" {
+		t.Error("Record get:", note, ok)
+	}
+	if _, ok := records[1].Get("unknown"); ok {
+		t.Error("Record get unknown")
+	}
+
+	maps, err := client.QueryMaps(sql)
+	if err != nil || len(maps) != 2 || maps[1]["id"] != int64(2) || maps[1]["amount"] != nil || maps[1]["score"] != 2.5 {
+		t.Error("Query maps:", maps, err)
+	}
+	if _, err = client.QueryMaps("SELECT * FROM unknown"); err == nil {
+		t.Error("Query maps of an unknown table")
+	}
+}
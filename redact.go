@@ -0,0 +1,295 @@
+package tsgmysqlutils
+
+/*
+ Redact the sensitive sql args and DSN secrets in the logs and hooks
+  Usage:
+	// always redacted
+	client.Exec("UPDATE user SET password = ? WHERE id = ?", tsgmysqlutils.Secret(password), id)
+
+	client.Redactor = tsgmysqlutils.NewRedactor().
+		RedactColumn("password", "id_card").
+		RedactSql(`(?i)^INSERT INTO user_token`, 1).
+		RedactPosition(5)
+
+	log.Println(tsgmysqlutils.MaskDSN(dsn))
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/*
+  The replacement of the redacted args and passwords
+*/
+const RedactedMask = "******"
+
+/*
+  The max sqls of the bound columns cached by a Redactor, the dynamic sqls evict the least recently used
+*/
+const redactCacheSize = 1024
+
+/*
+  A sql arg always redacted in the logs and hooks, passed to the driver as the wrapped value
+*/
+type SecretArg struct {
+	Arg interface{}
+}
+
+/*
+  Wrap a sensitive sql arg, eg: client.Exec(sql, tsgmysqlutils.Secret(password))
+*/
+func Secret(value interface{}) SecretArg {
+	return SecretArg{Arg: value}
+}
+
+/*
+  Implement driver.Valuer, the driver gets the wrapped value
+*/
+func (arg SecretArg) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(arg.Arg)
+}
+
+func (arg SecretArg) String() string {
+	return RedactedMask
+}
+
+/*
+  Mask the password of a go-sql-driver DSN or a "mysql://" URL
+*/
+func MaskDSN(dsn string) string {
+	if strings.HasPrefix(strings.ToLower(dsn), URLScheme+"://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return RedactedMask
+		}
+		// url.URL.Redacted masks the password by "xxxxx"
+		return strings.Replace(u.Redacted(), ":xxxxx@", ":"+RedactedMask+"@", 1)
+	}
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	colon := strings.Index(dsn[:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:colon+1] + RedactedMask + dsn[at:]
+}
+
+/*
+  The DSN of the configuration with the password masked
+*/
+func (config DBConfig) String() string {
+	return MaskDSN(config.DSN())
+}
+
+/*
+  The sql args redaction rules, the SecretArg args are always redacted
+*/
+type Redactor struct {
+	positions []int
+	sqlRules  []redactSqlRule
+	columns   map[string]bool
+	// sql => the bound columns of the placeholders, the LRU of redactCacheSize, the front is the most recently used
+	lock        sync.Mutex
+	bindColumns map[string]*list.Element
+	bindLru     *list.List
+}
+
+type bindColumnsEntry struct {
+	sql     string
+	columns []string
+}
+
+type redactSqlRule struct {
+	pattern *regexp.Regexp
+	// if empty, all args
+	positions []int
+}
+
+func NewRedactor() *Redactor {
+	var redactor Redactor
+	redactor.columns = make(map[string]bool)
+	return &redactor
+}
+
+/*
+  Redact the args at the positions (0-based) of all sqls
+*/
+func (redactor *Redactor) RedactPosition(positions ...int) *Redactor {
+	redactor.positions = append(redactor.positions, positions...)
+	return redactor
+}
+
+/*
+  Redact the args at the positions (0-based) of the sqls matching the regex, if no positions, all args,
+  panic if the regex is invalid
+*/
+func (redactor *Redactor) RedactSql(pattern string, positions ...int) *Redactor {
+	redactor.sqlRules = append(redactor.sqlRules, redactSqlRule{regexp.MustCompile(pattern), positions})
+	return redactor
+}
+
+/*
+  Redact the args bound to the columns (case insensitive), eg: "password = ?", "INSERT INTO user (name, password) VALUES (?, ?)"
+*/
+func (redactor *Redactor) RedactColumn(columns ...string) *Redactor {
+	for _, column := range columns {
+		redactor.columns[strings.ToLower(column)] = true
+	}
+	return redactor
+}
+
+/*
+  Get a copy of the args with the sensitive args replaced by RedactedMask, a nil redactor redacts the SecretArg args only
+*/
+func (redactor *Redactor) Redact(sql string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	redacted := make([]interface{}, len(args))
+	copy(redacted, args)
+	for i, arg := range redacted {
+		switch arg.(type) {
+		case SecretArg, *SecretArg:
+			redacted[i] = RedactedMask
+		}
+	}
+	if redactor == nil {
+		return redacted
+	}
+	mask := func(positions []int) {
+		for _, position := range positions {
+			if position >= 0 && position < len(redacted) {
+				redacted[position] = RedactedMask
+			}
+		}
+	}
+	mask(redactor.positions)
+	for _, rule := range redactor.sqlRules {
+		if !rule.pattern.MatchString(sql) {
+			continue
+		}
+		if len(rule.positions) == 0 {
+			for i := range redacted {
+				redacted[i] = RedactedMask
+			}
+			return redacted
+		}
+		mask(rule.positions)
+	}
+	if len(redactor.columns) > 0 {
+		for i, column := range redactor.getBindColumns(sql) {
+			if i < len(redacted) && redactor.columns[column] {
+				redacted[i] = RedactedMask
+			}
+		}
+	}
+	return redacted
+}
+
+func (redactor *Redactor) getBindColumns(sql string) []string {
+	redactor.lock.Lock()
+	if element, ok := redactor.bindColumns[sql]; ok {
+		redactor.bindLru.MoveToFront(element)
+		redactor.lock.Unlock()
+		return element.Value.(*bindColumnsEntry).columns
+	}
+	redactor.lock.Unlock()
+
+	columns := parseBindColumns(sql)
+	redactor.lock.Lock()
+	defer redactor.lock.Unlock()
+	if redactor.bindColumns == nil {
+		redactor.bindColumns = make(map[string]*list.Element)
+		redactor.bindLru = list.New()
+	}
+	if _, ok := redactor.bindColumns[sql]; !ok {
+		redactor.bindColumns[sql] = redactor.bindLru.PushFront(&bindColumnsEntry{sql, columns})
+		if redactor.bindLru.Len() > redactCacheSize {
+			oldest := redactor.bindLru.Remove(redactor.bindLru.Back()).(*bindColumnsEntry)
+			delete(redactor.bindColumns, oldest.sql)
+		}
+	}
+	return columns
+}
+
+var (
+	insertColumnsRegexp = regexp.MustCompile("(?is)^\\s*(?:INSERT|REPLACE)\\b[^(]*\\(([^)]*)\\)\\s*VALUES\\s*")
+	onDuplicateRegexp   = regexp.MustCompile("(?i)\\bON\\s+DUPLICATE\\s+KEY\\b")
+	compareColumnRegexp = regexp.MustCompile("(?is)([A-Za-z0-9_`.]+)\\s*(?:=|<=>|<>|!=|<=|>=|<|>|\\bLIKE|\\bIN\\s*\\([^)]*)\\s*$")
+)
+
+/*
+  Get the bound column (lower case, "" if unknown) of each "?" placeholder of the sql
+*/
+func parseBindColumns(sql string) []string {
+	placeholders := findPlaceholders(sql)
+	columns := make([]string, len(placeholders))
+	var insertColumns []string
+	valuesStart, valuesEnd := -1, -1
+	if match := insertColumnsRegexp.FindStringSubmatchIndex(sql); match != nil {
+		for _, column := range strings.Split(sql[match[2]:match[3]], ",") {
+			insertColumns = append(insertColumns, normalizeColumn(column))
+		}
+		valuesStart, valuesEnd = match[1], len(sql)
+		if end := onDuplicateRegexp.FindStringIndex(sql[valuesStart:]); end != nil {
+			valuesEnd = valuesStart + end[0]
+		}
+	}
+	valueIndex := 0
+	for i, position := range placeholders {
+		if position >= valuesStart && position < valuesEnd && len(insertColumns) > 0 {
+			columns[i] = insertColumns[valueIndex%len(insertColumns)]
+			valueIndex++
+			continue
+		}
+		before := sql[:position]
+		if len(before) > 256 {
+			before = before[len(before)-256:]
+		}
+		if match := compareColumnRegexp.FindStringSubmatch(before); match != nil {
+			columns[i] = normalizeColumn(match[1])
+		}
+	}
+	return columns
+}
+
+/*
+  Get the positions of the "?" placeholders outside the quoted strings, identifiers and comments
+*/
+func findPlaceholders(sql string) []int {
+	var positions []int
+	for i := 0; i < len(sql); i++ {
+		if end := sqlTextEnd(sql, i); end >= 0 {
+			i = end
+		} else if sql[i] == '?' {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+/*
+  `t`.`Password` => password
+*/
+func normalizeColumn(column string) string {
+	column = strings.TrimSpace(column)
+	if dot := strings.LastIndex(column, "."); dot >= 0 {
+		column = column[dot+1:]
+	}
+	return strings.ToLower(strings.Trim(column, "`"))
+}
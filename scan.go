@@ -0,0 +1,171 @@
+package tsgmysqlutils
+
+/*
+ Scan the rows into the struct fields by the "column" tags, the field plan is cached per struct type
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	db "database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*db.Scanner)(nil)).Elem()
+	// reflect.Type => *structPlan
+	structPlans sync.Map
+)
+
+/*
+  The column fields of a struct type: the "column" tags, or the field names ignoring case if untagged,
+  the fields of the embedded structs included, the `column:"-"` fields skipped
+*/
+type structPlan struct {
+	tagged map[string][]int
+	named  map[string][]int
+	// the tagged columns in the field order
+	columns []string
+}
+
+func getStructPlan(structType reflect.Type) *structPlan {
+	if plan, ok := structPlans.Load(structType); ok {
+		return plan.(*structPlan)
+	}
+	plan := &structPlan{tagged: make(map[string][]int), named: make(map[string][]int)}
+	plan.addFields(structType, nil)
+	actual, _ := structPlans.LoadOrStore(structType, plan)
+	return actual.(*structPlan)
+}
+
+func (plan *structPlan) addFields(structType reflect.Type, parent []int) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		tag := field.Tag.Get("column")
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct && !isScalarType(field.Type) {
+			plan.addFields(field.Type, index)
+			continue
+		}
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		if tag != "" {
+			// the outer fields win
+			if _, ok := plan.tagged[tag]; !ok {
+				plan.tagged[tag] = index
+				plan.columns = append(plan.columns, tag)
+			}
+			continue
+		}
+		name := strings.ToLower(field.Name)
+		if _, ok := plan.named[name]; !ok {
+			plan.named[name] = index
+		}
+	}
+}
+
+/*
+  Get the field index of the column, nil if none
+*/
+func (plan *structPlan) fieldIndex(column string) []int {
+	if index, ok := plan.tagged[column]; ok {
+		return index
+	}
+	return plan.named[strings.ToLower(column)]
+}
+
+/*
+  The types scanned from a single column: the non-struct types, time.Time and the sql.Scanner types
+*/
+func isScalarType(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || t == timeType || reflect.PointerTo(t).Implements(scannerType)
+}
+
+/*
+  The scanner of the rows of a result set into the struct values
+*/
+type structScanner struct {
+	indexes [][]int
+	loc     *time.Location
+}
+
+/*
+  Get the scanner of the columns, an error if a column has no field
+*/
+func (client *DBClient) newStructScanner(structType reflect.Type, columns []string) (*structScanner, error) {
+	plan := getStructPlan(structType)
+	scanner := &structScanner{indexes: make([][]int, len(columns)), loc: client.timeLocation()}
+	for i, column := range columns {
+		index := plan.fieldIndex(column)
+		if index == nil {
+			return nil, fmt.Errorf("%s column '%s' has no field in %s", MySQL, column, structType)
+		}
+		scanner.indexes[i] = index
+	}
+	return scanner, nil
+}
+
+/*
+  Scan the current row into the struct value, the prefix targets get the leading columns not in the scanner,
+  the time.Time fields accept the DATETIME values without parseTime=true
+*/
+func (scanner *structScanner) scan(rows *db.Rows, structValue reflect.Value, prefix ...interface{}) error {
+	targets := make([]interface{}, 0, len(prefix)+len(scanner.indexes))
+	targets = append(targets, prefix...)
+	var times map[int]*AggregateValue
+	for i, index := range scanner.indexes {
+		field := structValue.FieldByIndex(index)
+		if field.Type() == timeType {
+			if times == nil {
+				times = make(map[int]*AggregateValue)
+			}
+			times[i] = &AggregateValue{loc: scanner.loc}
+			targets = append(targets, times[i])
+			continue
+		}
+		targets = append(targets, field.Addr().Interface())
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+	for i, value := range times {
+		if !value.Valid {
+			continue
+		}
+		t, err := value.Time()
+		if err != nil {
+			return err
+		}
+		structValue.FieldByIndex(scanner.indexes[i]).Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+/*
+  Scan the current row into the scalar value of a single column, time.Time accepts the DATETIME values without parseTime=true
+*/
+func scanScalar(rows *db.Rows, dest interface{}, loc *time.Location, prefix ...interface{}) error {
+	t, ok := dest.(*time.Time)
+	if !ok {
+		return rows.Scan(append(prefix, dest)...)
+	}
+	value := AggregateValue{loc: loc}
+	if err := rows.Scan(append(prefix, &value)...); err != nil {
+		return err
+	}
+	if !value.Valid {
+		*t = time.Time{}
+		return nil
+	}
+	var err error
+	*t, err = value.Time()
+	return err
+}
@@ -0,0 +1,133 @@
+package tsgmysqlutils
+
+/*
+ Slow sql and slow connect thresholds, sampling
+  Usage:
+	dbConfig.SlowSqlThreshold = 200 * time.Millisecond
+	dbConfig.SlowSqlSampleRate = 0.1
+	dbConfig.SlowSqlLogInterval = time.Minute
+
+	// a nightly report query
+	ctx := tsgmysqlutils.WithSlowSqlThreshold(context.Background(), 10*time.Minute)
+	client.QueryListContext(ctx, orm, sql)
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	LogKeySuppressed = "suppressed"
+	// the max distinct sqls tracked by SlowSqlLogInterval
+	maxSampledSqls = 1024
+)
+
+type slowSqlThresholdKey struct{}
+
+/*
+  Override the slow sql threshold of the queries with the context, if < 0, the slow sql is not logged
+*/
+func WithSlowSqlThreshold(ctx context.Context, threshold time.Duration) context.Context {
+	return context.WithValue(ctx, slowSqlThresholdKey{}, threshold)
+}
+
+/*
+  Get the slow sql threshold, ms: the context override, DBConfig.SlowSqlThreshold or SlowSqlTimeoutMillisecond,
+  if < 0, the slow sql is not logged
+*/
+func (config DBConfig) getSlowSqlThreshold(ctx context.Context) int64 {
+	if ctx != nil {
+		if threshold, ok := ctx.Value(slowSqlThresholdKey{}).(time.Duration); ok {
+			return thresholdMillisecond(threshold)
+		}
+	}
+	if config.SlowSqlThreshold != 0 {
+		return thresholdMillisecond(config.SlowSqlThreshold)
+	}
+	return SlowSqlTimeoutMillisecond
+}
+
+/*
+  Get the slow connect threshold, ms: DBConfig.SlowConnThreshold or ConnDBTimeoutMillisecond,
+  if < 0, the slow connect is not logged
+*/
+func (config DBConfig) getSlowConnThreshold() int64 {
+	if config.SlowConnThreshold != 0 {
+		return thresholdMillisecond(config.SlowConnThreshold)
+	}
+	return ConnDBTimeoutMillisecond
+}
+
+func thresholdMillisecond(threshold time.Duration) int64 {
+	if threshold < 0 {
+		return -1
+	}
+	return threshold.Milliseconds()
+}
+
+/*
+  The slow sql sampler of a client: SlowSqlSampleRate and SlowSqlLogInterval
+*/
+type slowSqlSampler struct {
+	lock sync.Mutex
+	sqls map[string]*sampledSql
+}
+
+type sampledSql struct {
+	logged     time.Time
+	suppressed int
+}
+
+/*
+  Check the slow sql should be logged, return the suppressed count of the same sql since the last log
+*/
+func (client *DBClient) sampleSlowSql(sql string) (bool, int) {
+	rate := client.Config.SlowSqlSampleRate
+	if rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return false, 0
+	}
+	interval := client.Config.SlowSqlLogInterval
+	if interval <= 0 {
+		return true, 0
+	}
+	client.lock.Lock()
+	if client.sampler == nil {
+		client.sampler = &slowSqlSampler{sqls: make(map[string]*sampledSql)}
+	}
+	sampler := client.sampler
+	client.lock.Unlock()
+
+	sampler.lock.Lock()
+	defer sampler.lock.Unlock()
+	now := time.Now()
+	sampled, ok := sampler.sqls[sql]
+	if !ok {
+		if len(sampler.sqls) >= maxSampledSqls {
+			for key, value := range sampler.sqls {
+				if now.Sub(value.logged) >= interval {
+					delete(sampler.sqls, key)
+				}
+			}
+			if len(sampler.sqls) >= maxSampledSqls {
+				return true, 0
+			}
+		}
+		sampler.sqls[sql] = &sampledSql{logged: now}
+		return true, 0
+	}
+	if now.Sub(sampled.logged) < interval {
+		sampled.suppressed++
+		return false, 0
+	}
+	suppressed := sampled.suppressed
+	sampled.logged = now
+	sampled.suppressed = 0
+	return true, suppressed
+}
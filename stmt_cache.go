@@ -0,0 +1,303 @@
+package tsgmysqlutils
+
+/*
+ Prepared statements: the LRU cache by the sql, or no preparing
+  Usage:
+	// the 256 least recently used statements are kept prepared
+	dbConfig.StmtCacheSize = 256
+	client := tsgmysqlutils.NewDbClient(dbConfig)
+	stats := client.StmtCacheStats()
+
+	// no server side PREPARE, the driver interpolates the args
+	dbConfig.SkipPrepare = true
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"container/list"
+	"context"
+	db "database/sql"
+	"sync"
+)
+
+/*
+  The MySQL error number of a statement needs to be re-prepared, eg: the table altered
+*/
+const erNeedReprepare = 1615
+
+/*
+  The statement cache counters of a client
+*/
+type StmtCacheStats struct {
+	// the cached statements and the max
+	Size     int
+	Capacity int
+	Hits     int64
+	Misses   int64
+	// the least recently used statements closed
+	Evictions int64
+	// the statements closed by the connection errors
+	Invalidations int64
+}
+
+/*
+  The statement of a sql: a *db.Stmt, or the sql run by the DB or Tx directly
+*/
+type stmtRunner interface {
+	ExecContext(ctx context.Context, args ...interface{}) (db.Result, error)
+	QueryContext(ctx context.Context, args ...interface{}) (*db.Rows, error)
+	QueryRowContext(ctx context.Context, args ...interface{}) *db.Row
+}
+
+/*
+  The *db.DB or *db.Tx
+*/
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (db.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*db.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *db.Row
+}
+
+type directRunner struct {
+	conn sqlConn
+	sql  string
+}
+
+func (runner directRunner) ExecContext(ctx context.Context, args ...interface{}) (db.Result, error) {
+	return runner.conn.ExecContext(ctx, runner.sql, args...)
+}
+
+func (runner directRunner) QueryContext(ctx context.Context, args ...interface{}) (*db.Rows, error) {
+	return runner.conn.QueryContext(ctx, runner.sql, args...)
+}
+
+func (runner directRunner) QueryRowContext(ctx context.Context, args ...interface{}) *db.Row {
+	return runner.conn.QueryRowContext(ctx, runner.sql, args...)
+}
+
+/*
+  Skip preparing: SkipPrepare, or the params interpolateParams=true
+*/
+func (config DBConfig) skipPrepare() bool {
+	return config.SkipPrepare || config.Params["interpolateParams"] == "true"
+}
+
+/*
+  Get the statement runner of the event sql: the sql run directly if skip preparing, the cached statement if StmtCacheSize > 0,
+  or a new statement, the event tx: if nil, not in a transaction, the cached statements are bound to it by tx.Stmt,
+  the sqls of the expanded slice args are not cached, they vary by the slice lengths and evict the others,
+  closeTx: close the transaction statement on release, false if the rows outlive the call, it is closed with the transaction,
+  call release with the statement error after the statement, QueryRow: see rowErr
+*/
+func (client *DBClient) getRunner(ctx context.Context, event *QueryEvent, closeTx bool) (runner stmtRunner, release func(err error), err error) {
+	tx, sql := event.Tx, event.Sql
+	if client.Config.skipPrepare() {
+		if tx != nil {
+			return directRunner{tx, sql}, func(error) {}, nil
+		}
+		return directRunner{client.Db, sql}, func(error) {}, nil
+	}
+	cache := client.getStmtCache()
+	if cache == nil || event.expanded {
+		var stmt *db.Stmt
+		if tx == nil {
+			stmt, err = client.GetStmtContext(ctx, sql)
+		} else {
+			stmt, err = client.GetTxStmtContext(ctx, tx, sql)
+		}
+		if stmt == nil || err != nil {
+			return nil, nil, err
+		}
+		return stmt, func(error) {
+			if tx == nil || closeTx {
+				client.CloseStmt(stmt)
+			}
+		}, nil
+	}
+	entry, err := cache.acquire(ctx, client, sql)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx == nil {
+		return entry.stmt, func(err error) { cache.release(entry, err) }, nil
+	}
+	txStmt := tx.StmtContext(ctx, entry.stmt)
+	return txStmt, func(err error) {
+		if closeTx {
+			client.CloseStmt(txStmt)
+		}
+		cache.release(entry, err)
+	}, nil
+}
+
+/*
+  The error of the QueryRow statement to release, the query errors are deferred to Scan by sql.Row
+*/
+func rowErr(row *db.Row, err error) error {
+	if err == nil && row != nil {
+		return row.Err()
+	}
+	return err
+}
+
+/*
+  Get the statement cache counters, zero if no cache
+*/
+func (client *DBClient) StmtCacheStats() StmtCacheStats {
+	cache := client.getStmtCache()
+	if cache == nil {
+		return StmtCacheStats{}
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	stats := cache.stats
+	stats.Size = cache.lru.Len()
+	stats.Capacity = cache.capacity
+	return stats
+}
+
+/*
+  Get the statement cache, nil if StmtCacheSize is 0
+*/
+func (client *DBClient) getStmtCache() *stmtCache {
+	if client.Config.StmtCacheSize <= 0 {
+		return nil
+	}
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	if client.stmtCache == nil {
+		client.stmtCache = &stmtCache{capacity: client.Config.StmtCacheSize, entries: make(map[string]*list.Element), lru: list.New()}
+	}
+	return client.stmtCache
+}
+
+/*
+  The LRU cache of the prepared statements, the statements in use are closed after release
+*/
+type stmtCache struct {
+	lock     sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// the front is the most recently used
+	lru   *list.List
+	stats StmtCacheStats
+}
+
+type stmtCacheEntry struct {
+	sql     string
+	stmt    *db.Stmt
+	refs    int
+	removed bool
+}
+
+/*
+  Get the cached statement of the sql, prepare it if missed
+*/
+func (cache *stmtCache) acquire(ctx context.Context, client *DBClient, sql string) (*stmtCacheEntry, error) {
+	cache.lock.Lock()
+	if element, ok := cache.entries[sql]; ok {
+		entry := element.Value.(*stmtCacheEntry)
+		entry.refs++
+		cache.lru.MoveToFront(element)
+		cache.stats.Hits++
+		cache.lock.Unlock()
+		return entry, nil
+	}
+	cache.stats.Misses++
+	cache.lock.Unlock()
+
+	stmt, err := client.GetStmtContext(ctx, sql)
+	if stmt == nil || err != nil {
+		return nil, err
+	}
+	var closes []*db.Stmt
+	cache.lock.Lock()
+	var entry *stmtCacheEntry
+	if element, ok := cache.entries[sql]; ok {
+		// prepared concurrently
+		entry = element.Value.(*stmtCacheEntry)
+		cache.lru.MoveToFront(element)
+		closes = append(closes, stmt)
+	} else {
+		entry = &stmtCacheEntry{sql: sql, stmt: stmt}
+		cache.entries[sql] = cache.lru.PushFront(entry)
+		for cache.lru.Len() > cache.capacity {
+			oldest := cache.lru.Back().Value.(*stmtCacheEntry)
+			if stmt := cache.remove(oldest); stmt != nil {
+				closes = append(closes, stmt)
+			}
+			cache.stats.Evictions++
+		}
+	}
+	entry.refs++
+	cache.lock.Unlock()
+	closeStmts(closes)
+	return entry, nil
+}
+
+/*
+  Release the statement, it is closed if removed and not in use,
+  the connection errors and the re-prepare errors invalidate it
+*/
+func (cache *stmtCache) release(entry *stmtCacheEntry, err error) {
+	var closes []*db.Stmt
+	cache.lock.Lock()
+	entry.refs--
+	invalid := err != nil && (IsConnectionLost(err) || isNeedReprepare(err))
+	if invalid && !entry.removed {
+		cache.stats.Invalidations++
+	}
+	if invalid || entry.removed {
+		if stmt := cache.remove(entry); stmt != nil {
+			closes = append(closes, stmt)
+		}
+	}
+	cache.lock.Unlock()
+	closeStmts(closes)
+}
+
+/*
+  Remove the entry from the cache, return the statement to close if not in use, called with the lock
+*/
+func (cache *stmtCache) remove(entry *stmtCacheEntry) *db.Stmt {
+	if element, ok := cache.entries[entry.sql]; ok && element.Value == entry {
+		cache.lru.Remove(element)
+		delete(cache.entries, entry.sql)
+	}
+	entry.removed = true
+	if entry.refs > 0 || entry.stmt == nil {
+		return nil
+	}
+	stmt := entry.stmt
+	entry.stmt = nil
+	return stmt
+}
+
+/*
+  Remove all statements, called by CloseConn
+*/
+func (cache *stmtCache) clear() {
+	var closes []*db.Stmt
+	cache.lock.Lock()
+	for cache.lru.Len() > 0 {
+		if stmt := cache.remove(cache.lru.Front().Value.(*stmtCacheEntry)); stmt != nil {
+			closes = append(closes, stmt)
+		}
+	}
+	cache.lock.Unlock()
+	closeStmts(closes)
+}
+
+func closeStmts(stmts []*db.Stmt) {
+	for _, stmt := range stmts {
+		stmt.Close()
+	}
+}
+
+func isNeedReprepare(err error) bool {
+	number, ok := MySQLErrorNumber(err)
+	return ok && number == erNeedReprepare
+}
@@ -0,0 +1,91 @@
+package tsgmysqlutils
+
+/*
+ Statement cache test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"database/sql/driver"
+	"github.com/go-sql-driver/mysql"
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	client, fake := newFakeClient(t, map[string]fakeResult{
+		"UPDATE user SET name = 'x'":         {err: &mysql.MySQLError{Number: 1615, Message: "Prepared statement needs to be re-prepared"}},
+		"SELECT name FROM user WHERE id = 0": {err: &mysql.MySQLError{Number: 1615, Message: "Prepared statement needs to be re-prepared"}},
+		"SELECT name FROM user":              {columns: []string{"name"}, rows: [][]driver.Value{{"tony"}}},
+	})
+	client.Config.StmtCacheSize = 2
+	for _, sql := range []string{"DELETE FROM user WHERE id = ?", "DELETE FROM user WHERE id = ?", "DELETE FROM user WHERE gender = ?"} {
+		if _, err := client.Exec(sql, 1); err != nil {
+			t.Error("Exec cached:", err)
+		}
+	}
+	if stats := client.StmtCacheStats(); stats != (StmtCacheStats{Size: 2, Capacity: 2, Hits: 1, Misses: 2}) || fake.getOpenStmts() != 2 {
+		t.Error("Stmt cache stats:", stats, fake.getOpenStmts())
+	}
+	names, err := QueryAll[string](client, "SELECT name FROM user")
+	if err != nil || len(names) != 1 || names[0] != "tony" {
+		t.Error("Query cached:", names, err)
+	}
+	if stats := client.StmtCacheStats(); stats.Size != 2 || stats.Evictions != 1 || fake.getOpenStmts() != 2 {
+		t.Error("Stmt cache eviction:", stats, fake.getOpenStmts())
+	}
+
+	// the cached statement is bound to the transaction
+	tx, _ := client.TxBegin()
+	if _, err = client.TxExec(tx, "DELETE FROM user WHERE gender = ?", 2); err != nil {
+		t.Error("Tx exec cached:", err)
+	}
+	client.TxCommit(tx)
+	if stats := client.StmtCacheStats(); stats.Hits != 2 || fake.getOpenStmts() != 2 {
+		t.Error("Stmt cache tx:", stats, fake.getOpenStmts())
+	}
+
+	if _, err = client.Exec("UPDATE user SET name = 'x'"); !isNeedReprepare(err) {
+		t.Error("Exec re-prepare:", err)
+	}
+	if stats := client.StmtCacheStats(); stats.Size != 1 || stats.Invalidations != 1 || stats.Evictions != 2 || fake.getOpenStmts() != 1 {
+		t.Error("Stmt cache invalidation:", stats, fake.getOpenStmts())
+	}
+	// the QueryRow error is deferred to Scan, it invalidates too
+	row, err := client.QueryRow(nil, "SELECT name FROM user WHERE id = 0")
+	var name string
+	if err != nil || !isNeedReprepare(row.Scan(&name)) {
+		t.Error("Query row re-prepare:", err)
+	}
+	if stats := client.StmtCacheStats(); stats.Size != 1 || stats.Invalidations != 2 || fake.getOpenStmts() != 1 {
+		t.Error("Stmt cache query row invalidation:", stats, fake.getOpenStmts())
+	}
+	// the expanded slice args are not cached
+	for _, ids := range [][]int{{1}, {1, 2}, {1, 2, 3}} {
+		if _, err = client.Exec("DELETE FROM user WHERE id IN (?)", ids); err != nil {
+			t.Error("Exec slice args:", err)
+		}
+	}
+	if stats := client.StmtCacheStats(); stats.Size != 1 || stats.Misses != 5 || fake.getOpenStmts() != 1 {
+		t.Error("Stmt cache slice args:", stats, fake.getOpenStmts())
+	}
+	client.CloseConn()
+	if stats := client.StmtCacheStats(); stats.Size != 0 || fake.getOpenStmts() != 0 {
+		t.Error("Stmt cache close:", stats, fake.getOpenStmts())
+	}
+
+	// no cache: the statements are prepared and closed by each call, skip prepare: no cache either
+	client, fake = newFakeClient(t, nil)
+	client.Config.SkipPrepare = true
+	client.Config.StmtCacheSize = 2
+	if _, err = client.Exec("DELETE FROM user WHERE id = ?", 1); err != nil || fake.getLog()[0] != "exec DELETE FROM user WHERE id = ? [1]" {
+		t.Error("Exec skip prepare:", fake.getLog(), err)
+	}
+	if stats := client.StmtCacheStats(); stats.Misses != 0 || stats.Size != 0 || fake.getOpenStmts() != 0 {
+		t.Error("Stmt cache skip prepare:", stats, fake.getOpenStmts())
+	}
+	if params := (DBConfig{SkipPrepare: true}).getConnParams(); params["interpolateParams"] != "true" {
+		t.Error("Skip prepare params:", params)
+	}
+}
@@ -0,0 +1,172 @@
+package tsgmysqlutils
+
+/*
+ Stream the rows of the large result sets one by one, the next row is scanned after the current row is handled,
+ the rows and the statement are closed when the stream ends, breaks or fails,
+ the hooks and the slow sql log get the duration of the whole stream
+  Usage:
+	err := tsgmysqlutils.ForEach(client, func(tab1 WeTestTab1) error {
+		return encoder.Encode(tab1)
+	}, "SELECT * FROM we_test_tab1")
+
+	for tab1, err := range tsgmysqlutils.QueryIter[WeTestTab1](client, "SELECT * FROM we_test_tab1") {
+		if err != nil {
+			return err
+		}
+		if tab1.Id > 100 {
+			break
+		}
+	}
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"iter"
+)
+
+/*
+  Return it from a ForEach callback to stop the stream without an error
+*/
+var ErrBreak = errors.New(MySQL + " break the stream")
+
+/*
+  Call fn with each row as T: a struct by the "column" tags, or a scalar of a single column,
+  the first error of fn stops the stream and is returned, except ErrBreak, querier: a DBClient or a Tx
+*/
+func ForEach[T any](querier Querier, fn func(value T) error, sql string, args ...interface{}) error {
+	return ForEachContext[T](context.Background(), querier, fn, sql, args...)
+}
+
+/*
+  Call fn with each row as T,context
+*/
+func ForEachContext[T any](ctx context.Context, querier Querier, fn func(value T) error, sql string, args ...interface{}) error {
+	client, tx, err := querierClient(querier)
+	if err != nil {
+		return err
+	}
+	return streamRows[T](ctx, client, tx, fn, sql, args)
+}
+
+/*
+  Call fn with each row as T,transaction
+*/
+func TxForEach[T any](client *DBClient, tx *db.Tx, fn func(value T) error, sql string, args ...interface{}) error {
+	return TxForEachContext[T](context.Background(), client, tx, fn, sql, args...)
+}
+
+/*
+  Call fn with each row as T,transaction,context
+*/
+func TxForEachContext[T any](ctx context.Context, client *DBClient, tx *db.Tx, fn func(value T) error, sql string, args ...interface{}) error {
+	return streamRows[T](ctx, client, tx, fn, sql, args)
+}
+
+/*
+  Get the iterator of the rows as T, an error is yielded last with the zero T, the break stops the stream,
+  querier: a DBClient or a Tx
+*/
+func QueryIter[T any](querier Querier, sql string, args ...interface{}) iter.Seq2[T, error] {
+	return QueryIterContext[T](context.Background(), querier, sql, args...)
+}
+
+/*
+  Get the iterator of the rows as T,context
+*/
+func QueryIterContext[T any](ctx context.Context, querier Querier, sql string, args ...interface{}) iter.Seq2[T, error] {
+	client, tx, err := querierClient(querier)
+	if err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return queryIter[T](ctx, client, tx, sql, args)
+}
+
+/*
+  Get the iterator of the rows as T,transaction
+*/
+func TxQueryIter[T any](client *DBClient, tx *db.Tx, sql string, args ...interface{}) iter.Seq2[T, error] {
+	return TxQueryIterContext[T](context.Background(), client, tx, sql, args...)
+}
+
+/*
+  Get the iterator of the rows as T,transaction,context
+*/
+func TxQueryIterContext[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args ...interface{}) iter.Seq2[T, error] {
+	return queryIter[T](ctx, client, tx, sql, args)
+}
+
+func queryIter[T any](ctx context.Context, client *DBClient, tx *db.Tx, sql string, args []interface{}) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := streamRows[T](ctx, client, tx, func(value T) error {
+			if !yield(value, nil) {
+				return ErrBreak
+			}
+			return nil
+		}, sql, args)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+/*
+  Stream the rows by the hooks chain, the statement is closed with the rows
+*/
+func streamRows[T any](ctx context.Context, client *DBClient, tx *db.Tx, fn func(value T) error, sql string, args []interface{}) error {
+	// the callback error is returned unwrapped, the statement succeeded
+	var fnErr error
+	event := newQueryEvent(OpQueryStream, tx, sql, args)
+	err := client.runQuery(ctx, event, func(ctx context.Context) (err error) {
+		stmt, release, err := client.getRunner(ctx, event, true)
+		if stmt == nil || err != nil {
+			return err
+		}
+		rows, err := stmt.QueryContext(ctx, event.Args...)
+		if err != nil {
+			release(err)
+			client.logErrorSql(err, event.Sql, event.Args...)
+			return err
+		}
+		// the returned error, the scan errors included
+		defer func() { release(err) }()
+		defer rows.Close()
+		columns, err := rows.Columns()
+		if err != nil {
+			return client.scanError(err, event.Sql, event.Args...)
+		}
+		scan, err := newScanFunc[T](client, columns)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var value T
+			if value, err = scan(rows); err != nil {
+				return client.scanError(err, event.Sql, event.Args...)
+			}
+			if fnErr = fn(value); fnErr != nil {
+				return nil
+			}
+		}
+		if err = rows.Err(); err != nil {
+			return client.scanError(err, event.Sql, event.Args...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if fnErr == ErrBreak {
+		return nil
+	}
+	return fnErr
+}
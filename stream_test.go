@@ -0,0 +1,118 @@
+package tsgmysqlutils
+
+/*
+ Stream query test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"testing"
+)
+
+func TestQueryStream(t *testing.T) {
+	rows := make([][]driver.Value, 100)
+	for i := range rows {
+		rows[i] = []driver.Value{int64(i + 1), []byte(fmt.Sprint("name", i+1))}
+	}
+	sql := "SELECT id, name FROM tab"
+	client, fake := newFakeClient(t, map[string]fakeResult{
+		sql:                    {columns: []string{"id", "name"}, rows: rows},
+		"SELECT name FROM tab": {columns: []string{"name"}, rows: [][]driver.Value{{[]byte("tony")}}},
+	})
+	var events []*QueryEvent
+	client.AddHook(HookFuncs{After: func(ctx context.Context, event *QueryEvent) {
+		events = append(events, event)
+	}})
+
+	count := 0
+	err := ForEach(client, func(tab genericTab) error {
+		count++
+		if tab.Id != int64(count) {
+			t.Error("For each row:", tab)
+		}
+		return nil
+	}, sql)
+	if err != nil || count != 100 || len(events) != 1 || events[0].Op != OpQueryStream {
+		t.Error("For each:", count, err, events)
+	}
+
+	count = 0
+	err = ForEach(client, func(tab genericTab) error {
+		count++
+		if count == 10 {
+			return ErrBreak
+		}
+		return nil
+	}, sql)
+	if err != nil || count != 10 {
+		t.Error("For each break:", count, err)
+	}
+	callbackErr := errors.New("test callback error")
+	err = ForEach(client, func(tab genericTab) error {
+		return callbackErr
+	}, sql)
+	if err != callbackErr || events[2].Err != nil {
+		t.Error("For each callback error:", err)
+	}
+
+	count = 0
+	for tab, err := range QueryIter[genericTab](client, sql) {
+		if err != nil {
+			t.Fatal("Query iter:", err)
+		}
+		count++
+		if tab.Id == 20 {
+			break
+		}
+	}
+	if count != 20 {
+		t.Error("Query iter break:", count)
+	}
+	count = 0
+	var iterErr error
+	for _, err := range QueryIter[genericTab](client, "SELECT id, name FROM unknown") {
+		count++
+		iterErr = err
+	}
+	if count != 1 || iterErr == nil || ErrorSql(iterErr) != "SELECT id, name FROM unknown" {
+		t.Error("Query iter error:", count, iterErr)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "test panic" {
+				t.Error("For each panic:", r)
+			}
+		}()
+		ForEach(client, func(tab genericTab) error {
+			panic("test panic")
+		}, sql)
+	}()
+	if last := events[len(events)-1]; last.Err != errQueryPanic {
+		t.Error("For each panic event:", last.Err)
+	}
+	if open := fake.getOpenStmts(); open != 0 {
+		t.Error("Stream open stmts:", open)
+	}
+
+	// the scan errors are released to the statement cache, the lost connections invalidate the statement
+	client.Config.StmtCacheSize = 2
+	err = ForEach(client, func(name lostConnName) error { return nil }, "SELECT name FROM tab")
+	if stats := client.StmtCacheStats(); !IsConnectionLost(err) || stats.Invalidations != 1 || stats.Size != 0 {
+		t.Error("For each scan error:", err, stats)
+	}
+}
+
+// A column of the lost connection scan errors
+type lostConnName string
+
+func (name *lostConnName) Scan(value interface{}) error {
+	return mysql.ErrInvalidConn
+}
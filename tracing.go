@@ -0,0 +1,366 @@
+package tsgmysqlutils
+
+/*
+ Tracing spans of the queries and transactions, OpenTelemetry style
+  Usage:
+	// adapt the OpenTelemetry tracer, or any tracer, by the Tracer and Span interfaces
+	client.Tracer = otelTracer{tracer: otel.Tracer("mysql")}
+
+	// the spans in memory, eg: the tests
+	recorder := tsgmysqlutils.NewSpanRecorder()
+	client.Tracer = recorder
+	client.Exec(sql, args...)
+	spans := recorder.Spans()
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+  The span attribute keys
+*/
+const (
+	SpanKeyDbSystem       = "db.system"
+	SpanKeyDbName         = "db.name"
+	SpanKeyHost           = "server.address"
+	SpanKeyPort           = "server.port"
+	SpanKeyDbStatement    = "db.statement"
+	SpanKeyDbOperation    = "db.operation"
+	SpanKeyRowsAffected   = "db.rows_affected"
+	SpanKeyInTx           = "db.in_transaction"
+	SpanKeyTxStatements   = "db.transaction.statements"
+	SpanKeyTxRowsAffected = "db.transaction.rows_affected"
+	SpanKeyTxResult       = "db.transaction.result"
+	// the db.system value
+	DbSystemMySQL = "mysql"
+)
+
+/*
+  The span names, the query spans are named by SpanNamePrefix + QueryEvent.Op, eg: "mysql.Exec"
+*/
+const (
+	SpanNamePrefix = DbSystemMySQL + "."
+	SpanNameTx     = SpanNamePrefix + "Tx"
+)
+
+/*
+  The transaction results of the SpanKeyTxResult attribute
+*/
+const (
+	TxResultCommit   = "commit"
+	TxResultRollback = "rollback"
+)
+
+type SpanAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+/*
+  The tracer of a client, implement it to adapt a tracing system:
+  Start a span as the child of the span of the context, or the parent if not nil,
+  return the context carrying the span
+*/
+type Tracer interface {
+	Start(ctx context.Context, name string, parent Span, attrs ...SpanAttribute) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attrs ...SpanAttribute)
+	// a non-nil error marks the span failed
+	RecordError(err error)
+	End()
+}
+
+/*
+  The client span attributes: db.system, db.name, host and port
+*/
+func (client *DBClient) spanAttributes() []SpanAttribute {
+	attrs := []SpanAttribute{{SpanKeyDbSystem, DbSystemMySQL}, {SpanKeyDbName, client.Config.DbName}}
+	if client.Config.DbHost != "" {
+		attrs = append(attrs, SpanAttribute{SpanKeyHost, client.Config.DbHost})
+		if client.Config.DbPort > 0 {
+			attrs = append(attrs, SpanAttribute{SpanKeyPort, client.Config.DbPort})
+		}
+	}
+	return attrs
+}
+
+/*
+  The built-in hook opens a span per statement, the statements of a transaction are the children of the transaction span
+*/
+type tracingHook struct {
+	tracer Tracer
+}
+
+type tracingSpanKey struct{}
+
+func (hook tracingHook) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	var parent Span
+	if event.Tx != nil {
+		if txSpan := event.Client.getTxSpan(event.Tx); txSpan != nil {
+			parent = txSpan.span
+		}
+	}
+	attrs := append(event.Client.spanAttributes(), SpanAttribute{SpanKeyDbOperation, event.Op}, SpanAttribute{SpanKeyInTx, event.InTx})
+	ctx, span := hook.tracer.Start(ctx, SpanNamePrefix+event.Op, parent, attrs...)
+	return context.WithValue(ctx, tracingSpanKey{}, span), nil
+}
+
+func (hook tracingHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	span, ok := ctx.Value(tracingSpanKey{}).(Span)
+	if !ok {
+		return
+	}
+	// the statement rewritten by the hooks
+	span.SetAttributes(SpanAttribute{SpanKeyDbStatement, NormalizeSql(event.Sql)})
+	if event.RowsAffected >= 0 {
+		span.SetAttributes(SpanAttribute{SpanKeyRowsAffected, event.RowsAffected})
+	}
+	if event.Err != nil {
+		span.RecordError(event.Err)
+	}
+	span.End()
+	if event.Tx != nil && event.Err == nil {
+		if txSpan := event.Client.getTxSpan(event.Tx); txSpan != nil {
+			txSpan.add(event.RowsAffected)
+		}
+	}
+}
+
+/*
+  The span of a transaction, from TxBegin to TxCommit or TxRollback
+*/
+type txSpan struct {
+	span Span
+	// stop ending the span when the begin context is done
+	stop         func() bool
+	lock         sync.Mutex
+	statements   int
+	rowsAffected int64
+}
+
+func (txSpan *txSpan) add(rowsAffected int64) {
+	txSpan.lock.Lock()
+	defer txSpan.lock.Unlock()
+	txSpan.statements++
+	if rowsAffected > 0 {
+		txSpan.rowsAffected += rowsAffected
+	}
+}
+
+/*
+  Start the transaction span, if the client has a Tracer, the span ends when the transaction ends
+*/
+func (client *DBClient) startTxSpan(ctx context.Context) Span {
+	if client.Tracer == nil {
+		return nil
+	}
+	_, span := client.Tracer.Start(ctx, SpanNameTx, nil, client.spanAttributes()...)
+	return span
+}
+
+func (client *DBClient) addTxSpan(ctx context.Context, tx *db.Tx, span Span) {
+	ts := &txSpan{span: span}
+	client.lock.Lock()
+	if client.txSpans == nil {
+		client.txSpans = make(map[*db.Tx]*txSpan)
+	}
+	client.txSpans[tx] = ts
+	client.lock.Unlock()
+	// database/sql rolls back the transaction when the begin context is done
+	ts.stop = context.AfterFunc(ctx, func() {
+		client.endTxSpan(tx, TxResultRollback, ctx.Err())
+	})
+}
+
+func (client *DBClient) getTxSpan(tx *db.Tx) *txSpan {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.txSpans[tx]
+}
+
+/*
+  End the transaction span, skipped if the span is ended or the client has no Tracer
+*/
+func (client *DBClient) endTxSpan(tx *db.Tx, result string, err error) {
+	client.lock.Lock()
+	ts, ok := client.txSpans[tx]
+	delete(client.txSpans, tx)
+	client.lock.Unlock()
+	if !ok {
+		return
+	}
+	if ts.stop != nil {
+		ts.stop()
+	}
+	ts.lock.Lock()
+	ts.span.SetAttributes(SpanAttribute{SpanKeyTxResult, result}, SpanAttribute{SpanKeyTxStatements, ts.statements},
+		SpanAttribute{SpanKeyTxRowsAffected, ts.rowsAffected})
+	ts.lock.Unlock()
+	if err != nil {
+		ts.span.RecordError(err)
+	}
+	ts.span.End()
+}
+
+var (
+	sqlWhitespaceRegexp = regexp.MustCompile(`\s+`)
+	sqlValueListRegexp  = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+)
+
+/*
+  Normalize a sql for the spans and metrics: the string and number literals replaced by "?",
+  the comments removed, the whitespaces collapsed, the "?" lists collapsed: IN (?, ?, ?) => IN (?)
+*/
+func NormalizeSql(sql string) string {
+	builder := strings.Builder{}
+	builder.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			// string literal
+			for i++; i < len(sql) && sql[i] != c; i++ {
+				if sql[i] == '\\' {
+					i++
+				}
+			}
+			builder.WriteByte('?')
+		case c == '`':
+			end := strings.IndexByte(sql[i+1:], '`')
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				i = len(sql)
+				continue
+			}
+			builder.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			builder.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			builder.WriteByte(' ')
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(sql[i-1])):
+			for i+1 < len(sql) && (isIdentByte(sql[i+1]) || sql[i+1] == '.') {
+				i++
+			}
+			builder.WriteByte('?')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	normalized := sqlWhitespaceRegexp.ReplaceAllString(strings.TrimSpace(builder.String()), " ")
+	return sqlValueListRegexp.ReplaceAllString(normalized, "(?)")
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+/*
+  An in-memory tracer records the ended spans, eg: the tests
+*/
+type SpanRecorder struct {
+	lock   sync.Mutex
+	nextId int
+	spans  []*RecordedSpan
+}
+
+type RecordedSpan struct {
+	Id       int
+	ParentId int
+	Name     string
+	Attrs    map[string]interface{}
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+	Ended    bool
+
+	recorder *SpanRecorder
+}
+
+type recordedSpanKey struct{}
+
+func NewSpanRecorder() *SpanRecorder {
+	return new(SpanRecorder)
+}
+
+func (recorder *SpanRecorder) Start(ctx context.Context, name string, parent Span, attrs ...SpanAttribute) (context.Context, Span) {
+	recorder.lock.Lock()
+	recorder.nextId++
+	span := &RecordedSpan{Id: recorder.nextId, Name: name, Attrs: make(map[string]interface{}), Start: time.Now(), recorder: recorder}
+	recorder.lock.Unlock()
+	if parentSpan, ok := parent.(*RecordedSpan); ok {
+		span.ParentId = parentSpan.Id
+	} else if parentSpan, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok {
+		span.ParentId = parentSpan.Id
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+/*
+  Get the ended spans in the end order
+*/
+func (recorder *SpanRecorder) Spans() []*RecordedSpan {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	spans := make([]*RecordedSpan, len(recorder.spans))
+	copy(spans, recorder.spans)
+	return spans
+}
+
+func (recorder *SpanRecorder) Reset() {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.spans = nil
+}
+
+func (span *RecordedSpan) SetAttributes(attrs ...SpanAttribute) {
+	span.recorder.lock.Lock()
+	defer span.recorder.lock.Unlock()
+	for _, attr := range attrs {
+		span.Attrs[attr.Key] = attr.Value
+	}
+}
+
+func (span *RecordedSpan) RecordError(err error) {
+	span.recorder.lock.Lock()
+	defer span.recorder.lock.Unlock()
+	span.Err = err
+}
+
+func (span *RecordedSpan) End() {
+	span.recorder.lock.Lock()
+	defer span.recorder.lock.Unlock()
+	if span.Ended {
+		return
+	}
+	span.Ended = true
+	span.Duration = time.Since(span.Start)
+	span.recorder.spans = append(span.recorder.spans, span)
+}
+
+func (span *RecordedSpan) String() string {
+	return span.Name + "#" + strconv.Itoa(span.Id)
+}
@@ -0,0 +1,97 @@
+package tsgmysqlutils
+
+/*
+ Query tracing test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTracing(t *testing.T) {
+	normalized := map[string]string{
+		"SELECT * FROM  user\n WHERE name = 'tony' AND id IN (1, 2, 3) -- comment\n": "SELECT * FROM user WHERE name = ? AND id IN (?)",
+		"INSERT INTO `t1` (a, b) VALUES (?, ?), (?, ?) /* batch */":                  "INSERT INTO `t1` (a, b) VALUES (?), (?)",
+		"UPDATE t2 SET note = \"it\\\"s\", score = 1.5e3 WHERE id = ?":               "UPDATE t2 SET note = ?, score = ? WHERE id = ?",
+	}
+	for sql, expected := range normalized {
+		if normal := NormalizeSql(sql); normal != expected {
+			t.Error("Normalize sql:", sql, normal)
+		}
+	}
+
+	recorder := NewSpanRecorder()
+	client := newTestClient()
+	client.Config.DbPort = 3306
+	client.Tracer = recorder
+
+	event := newQueryEvent(OpExec, nil, "DELETE FROM user WHERE id = 1", nil)
+	client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		event.RowsAffected = 2
+		return nil
+	})
+	spans := recorder.Spans()
+	if len(spans) != 1 || spans[0].Name != "mysql.Exec" || spans[0].Err != nil {
+		t.Fatal("Exec spans:", spans)
+	}
+	attrs := spans[0].Attrs
+	if attrs[SpanKeyDbSystem] != DbSystemMySQL || attrs[SpanKeyDbName] != "test" || attrs[SpanKeyHost] != "127.0.0.1" ||
+		attrs[SpanKeyDbStatement] != "DELETE FROM user WHERE id = ?" || attrs[SpanKeyRowsAffected] != int64(2) {
+		t.Error("Exec span attributes:", attrs)
+	}
+
+	recorder.Reset()
+	tx := new(db.Tx)
+	client.addTxSpan(context.Background(), tx, client.startTxSpan(context.Background()))
+	queryErr := errors.New("test query error")
+	for i, err := range []error{nil, nil, queryErr} {
+		event = newQueryEvent(OpExec, tx, "UPDATE user SET age = ? WHERE id = ?", []interface{}{18, i})
+		client.runQuery(context.Background(), event, func(ctx context.Context) error {
+			event.RowsAffected = 1
+			return err
+		})
+	}
+	event = newQueryEvent(OpQueryRow, tx, "SELECT * FROM user WHERE id = ?", []interface{}{1})
+	client.runQuery(context.Background(), event, func(ctx context.Context) error {
+		return nil
+	})
+	client.endTxSpan(tx, TxResultCommit, nil)
+	client.endTxSpan(tx, TxResultRollback, nil)
+	spans = recorder.Spans()
+	if len(spans) != 5 {
+		t.Fatal("Tx spans:", spans)
+	}
+	txSpan := spans[4]
+	if txSpan.Name != SpanNameTx || txSpan.Attrs[SpanKeyTxResult] != TxResultCommit ||
+		txSpan.Attrs[SpanKeyTxStatements] != 3 || txSpan.Attrs[SpanKeyTxRowsAffected] != int64(2) {
+		t.Error("Tx span:", txSpan, txSpan.Attrs)
+	}
+	for _, span := range spans[:4] {
+		if span.ParentId != txSpan.Id || span.Attrs[SpanKeyInTx] != true {
+			t.Error("Tx child span:", span, span.ParentId)
+		}
+	}
+	if !errors.Is(spans[2].Err, queryErr) || spans[3].Name != "mysql.QueryRow" || spans[3].Attrs[SpanKeyRowsAffected] != nil {
+		t.Error("Tx query spans:", spans[2].Err, spans[3].Attrs)
+	}
+
+	recorder.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	client.addTxSpan(ctx, tx, client.startTxSpan(ctx))
+	cancel()
+	// the span is ended by context.AfterFunc in its own goroutine
+	for deadline := time.Now().Add(5 * time.Second); len(recorder.Spans()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	spans = recorder.Spans()
+	if len(spans) != 1 || spans[0].Attrs[SpanKeyTxResult] != TxResultRollback || spans[0].Err != context.Canceled {
+		t.Error("Canceled tx span:", spans)
+	}
+}
@@ -0,0 +1,552 @@
+package tsgmysqlutils
+
+/*
+ Transactions: commit if the function succeeded, otherwise rollback, the deadlocks may be retried,
+ the isolation level, read only and the session variables of a transaction, see TxOptions,
+ the nested transactions are the savepoints of the outermost transaction
+  Usage:
+	err := client.WithTx(ctx, &tsgmysqlutils.TxOptions{Retries: 3}, func(tx *tsgmysqlutils.Tx) error {
+		id, err := client.TxExecContext(ctx, tx.Tx, sql1)
+		if err != nil {
+			return err
+		}
+		_, err = client.TxExecContext(ctx, tx.Tx, sql2, id)
+		return err
+	})
+
+	// a ledger update, waits 3 seconds for the row locks
+	tx, err := client.BeginTx(ctx, &tsgmysqlutils.TxOptions{Isolation: sql.LevelSerializable, LockWaitTimeout: 3 * time.Second})
+	// a report, may run on a replica
+	tx, err := client.BeginTx(ctx, &tsgmysqlutils.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true})
+
+	// nested: SAVEPOINT, the context of the transaction nests the BeginTx and WithTx of the called services
+	err := client.WithTx(ctx, nil, func(tx *tsgmysqlutils.Tx) error {
+		ctx := tsgmysqlutils.ContextWithTx(ctx, tx)
+		order.Create(ctx)
+		// rolled back to the savepoint if failed, the order is kept
+		return client.WithTx(ctx, nil, func(tx *tsgmysqlutils.Tx) error {
+			return coupon.Use(ctx)
+		})
+	})
+
+	// the callbacks after the outermost transaction ends, eg: publish the events, invalidate the caches
+	err := client.WithTx(ctx, nil, func(tx *tsgmysqlutils.Tx) error {
+		tx.OnCommit(func() error { return cache.Delete(orderKey) })
+		tx.OnRollback(func() error { return stock.Release(orderId) })
+		...
+	})
+
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  The transaction options
+*/
+type TxOptions struct {
+	// if 0, the server default, MySQL: sql.LevelReadUncommitted, LevelReadCommitted, LevelRepeatableRead, LevelSerializable
+	Isolation db.IsolationLevel
+	// START TRANSACTION READ ONLY, the writes fail, eg: the reports
+	ReadOnly bool
+	// innodb_lock_wait_timeout of the transaction, rounded up to seconds, if 0, the session default
+	LockWaitTimeout time.Duration
+	// the session variables of the transaction, eg: "max_execution_time": 1000,
+	// they are set before begin and restored after commit or rollback on a dedicated connection
+	Session map[string]interface{}
+	// WithTx retries the whole function on the deadlocks and the lock wait timeouts, see IsRetryable
+	Retries int
+	// the first retry backoff, doubled each retry up to MaxTxRetryBackoff, jittered, if 0, DefaultTxRetryBackoff
+	RetryBackoff time.Duration
+}
+
+/*
+  A transaction of the client, Tx is for the DBClient Tx* methods, the nested transactions share it
+*/
+type Tx struct {
+	Tx     *db.Tx
+	client *DBClient
+	// the context of the begin, the savepoint statements use it
+	ctx context.Context
+	// 0: the outermost transaction, > 0: the savepoint depth of a nested transaction
+	depth int
+	// the outermost transaction, itself if depth is 0
+	root *Tx
+	// the open nested transactions of the outermost, the innermost is the last
+	nested []*Tx
+	done   bool
+	// the dedicated connection of the session variables, nil if none
+	conn *db.Conn
+	// restore the session variables
+	restoreSql  string
+	restoreArgs []interface{}
+	// the callbacks after commit or rollback, in the order of registration
+	onCommit   []func() error
+	onRollback []func() error
+}
+
+/*
+  The errors of the commit or the rollback callbacks, Committed: the transaction committed,
+  match the callback errors by errors.Is or errors.As
+*/
+type TxCallbackError struct {
+	Committed bool
+	Errs      []error
+}
+
+func (e *TxCallbackError) Error() string {
+	result := "rollback"
+	if e.Committed {
+		result = "commit"
+	}
+	return fmt.Sprintf("%s tx %s callbacks failed: %v", MySQL, result, errors.Join(e.Errs...))
+}
+
+func (e *TxCallbackError) Unwrap() []error {
+	return e.Errs
+}
+
+type txKey struct{}
+
+/*
+  Get the context of the transaction, BeginTx and WithTx of the context begin the nested transactions
+*/
+func ContextWithTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+/*
+  Get the transaction of the context, nil if none
+*/
+func TxFromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(txKey{}).(*Tx)
+	return tx
+}
+
+/*
+  Get the open transaction of the client in the context, nil if none
+*/
+func (client *DBClient) contextTx(ctx context.Context) *Tx {
+	if tx := TxFromContext(ctx); tx != nil && tx.client == client && !tx.isDone() {
+		return tx
+	}
+	return nil
+}
+
+/*
+  Begin the transaction, opts: if nil, the defaults,
+  the transaction is rolled back if the context is done before commit,
+  a nested transaction if the context has an open transaction of the client, see ContextWithTx, the opts are ignored
+*/
+func (client *DBClient) BeginTx(ctx context.Context, opts *TxOptions) (*Tx, error) {
+	if parent := client.contextTx(ctx); parent != nil {
+		return parent.innermost().Begin(ctx)
+	}
+	var txOpts *db.TxOptions
+	if opts != nil {
+		txOpts = &db.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	}
+	session, err := opts.session()
+	if err != nil {
+		return nil, err
+	}
+	if len(session) == 0 {
+		tx, err := client.TxBeginContext(ctx, txOpts)
+		if err != nil {
+			return nil, err
+		}
+		root := &Tx{Tx: tx, client: client, ctx: ctx}
+		root.root = root
+		return root, nil
+	}
+	conn, err := client.Db.Conn(ctx)
+	if err != nil {
+		client.logError(MySQL+" tx connection failed", err)
+		return nil, err
+	}
+	tx := &Tx{client: client, ctx: ctx, conn: conn}
+	tx.root = tx
+	if err = tx.setSession(ctx, session); err == nil {
+		tx.Tx, err = client.beginTx(ctx, conn, txOpts)
+	}
+	if err != nil {
+		tx.release()
+		return nil, err
+	}
+	return tx, nil
+}
+
+/*
+  Get the session variables of the options, the names are checked
+*/
+func (opts *TxOptions) session() (map[string]interface{}, error) {
+	if opts == nil || opts.LockWaitTimeout <= 0 && len(opts.Session) == 0 {
+		return nil, nil
+	}
+	session := make(map[string]interface{}, len(opts.Session)+1)
+	for name, value := range opts.Session {
+		if !isIdent(name) {
+			return nil, fmt.Errorf("%s invalid session variable name '%s'", MySQL, name)
+		}
+		session[name] = value
+	}
+	if opts.LockWaitTimeout > 0 {
+		session["innodb_lock_wait_timeout"] = int64(math.Ceil(opts.LockWaitTimeout.Seconds()))
+	}
+	return session, nil
+}
+
+/*
+  Save the current values of the session variables for the restore and set the new values
+*/
+func (tx *Tx) setSession(ctx context.Context, session map[string]interface{}) error {
+	names := make([]string, 0, len(session))
+	for name := range session {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	selects := make([]string, len(names))
+	sets := make([]string, len(names))
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		selects[i] = "@@SESSION." + name
+		sets[i] = name + " = ?"
+		values[i] = session[name]
+	}
+	sql := "SELECT " + strings.Join(selects, ", ")
+	olds := make([]interface{}, len(names))
+	targets := make([]interface{}, len(names))
+	for i := range olds {
+		targets[i] = &olds[i]
+	}
+	if err := tx.conn.QueryRowContext(ctx, sql).Scan(targets...); err != nil {
+		tx.client.logErrorSql(err, sql)
+		return err
+	}
+	for i, old := range olds {
+		olds[i] = sessionValue(old)
+	}
+	sql = "SET SESSION " + strings.Join(sets, ", ")
+	tx.restoreSql, tx.restoreArgs = sql, olds
+	if _, err := tx.conn.ExecContext(ctx, sql, values...); err != nil {
+		tx.client.logErrorSql(err, sql, values...)
+		return err
+	}
+	return nil
+}
+
+/*
+  The text values of the numeric variables are converted, MySQL rejects the strings of them
+*/
+func sessionValue(value interface{}) interface{} {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	if number, err := strconv.ParseInt(string(bytes), 10, 64); err == nil {
+		return number
+	}
+	if number, err := strconv.ParseFloat(string(bytes), 64); err == nil {
+		return number
+	}
+	return string(bytes)
+}
+
+/*
+  Restore the session variables and release the dedicated connection,
+  the connection is discarded if the restore failed
+*/
+func (tx *Tx) release() {
+	if tx.conn == nil {
+		return
+	}
+	if tx.restoreSql != "" {
+		if _, err := tx.conn.ExecContext(context.Background(), tx.restoreSql, tx.restoreArgs...); err != nil {
+			tx.client.logErrorSql(err, tx.restoreSql, tx.restoreArgs...)
+			tx.conn.Raw(func(interface{}) error {
+				return driver.ErrBadConn
+			})
+		}
+	}
+	tx.conn.Close()
+	tx.conn = nil
+}
+
+/*
+  Get the client of the transaction
+*/
+func (tx *Tx) Client() *DBClient {
+	return tx.client
+}
+
+/*
+  Get the savepoint depth, 0: the outermost transaction
+*/
+func (tx *Tx) Depth() int {
+	return tx.depth
+}
+
+/*
+  Begin a nested transaction: SAVEPOINT, tx must be the innermost open transaction
+*/
+func (tx *Tx) Begin(ctx context.Context) (*Tx, error) {
+	if err := tx.checkInnermost(); err != nil {
+		return nil, err
+	}
+	nested := &Tx{Tx: tx.Tx, client: tx.client, ctx: ctx, depth: tx.depth + 1, root: tx.root}
+	if _, err := tx.client.TxExecContext(ctx, tx.Tx, "SAVEPOINT "+nested.savepoint()); err != nil {
+		return nil, err
+	}
+	tx.root.nested = append(tx.root.nested, nested)
+	return nested, nil
+}
+
+/*
+  Commit the transaction, return the commit error, sql.ErrTxDone if committed or rolled back,
+  nested: RELEASE SAVEPOINT, the changes are committed with the outermost transaction
+*/
+func (tx *Tx) Commit() error {
+	if tx.depth == 0 {
+		// no second commit or rollback of the driver, eg: defer tx.Rollback() after Commit
+		if tx.done {
+			return db.ErrTxDone
+		}
+		tx.done = true
+		tx.endAllNested()
+		err := tx.client.commitTx(tx.Tx)
+		tx.release()
+		if err != nil {
+			return joinCallbackError(err, tx.runCallbacks(false))
+		}
+		return tx.runCallbacks(true)
+	}
+	if err := tx.checkInnermost(); err != nil {
+		return err
+	}
+	// the transaction is still open if the release failed, roll it back
+	if _, err := tx.client.TxExecContext(tx.ctx, tx.Tx, "RELEASE SAVEPOINT "+tx.savepoint()); err != nil {
+		return err
+	}
+	tx.endNested()
+	// the callbacks wait for the outer transaction
+	parent := tx.innermost()
+	parent.onCommit = append(parent.onCommit, tx.onCommit...)
+	parent.onRollback = append(parent.onRollback, tx.onRollback...)
+	return nil
+}
+
+/*
+  Rollback the transaction, sql.ErrTxDone if committed or rolled back,
+  nested: ROLLBACK TO SAVEPOINT, the outer changes are kept, the transaction is still open if it failed
+*/
+func (tx *Tx) Rollback() error {
+	if tx.depth == 0 {
+		if tx.done {
+			return db.ErrTxDone
+		}
+		tx.done = true
+		tx.endAllNested()
+		err := tx.client.rollbackTx(tx.Tx)
+		tx.release()
+		return joinCallbackError(err, tx.runCallbacks(false))
+	}
+	if err := tx.checkInnermost(); err != nil {
+		return err
+	}
+	// the transaction is still open if the rollback failed, the changes are kept in the outer transaction
+	if _, err := tx.client.TxExecContext(tx.ctx, tx.Tx, "ROLLBACK TO SAVEPOINT "+tx.savepoint()); err != nil {
+		return err
+	}
+	tx.endNested()
+	_, err := tx.client.TxExecContext(tx.ctx, tx.Tx, "RELEASE SAVEPOINT "+tx.savepoint())
+	return joinCallbackError(err, tx.runCallbacks(false))
+}
+
+/*
+  Register fn to run after the transaction committed, the callbacks run in the order of registration,
+  nested: after the outermost transaction committed, also if still open, never run if the transaction or an outer one is rolled back,
+  or the commit failed, their errors are returned by Commit as a *TxCallbackError, fn is ignored if the transaction is done
+*/
+func (tx *Tx) OnCommit(fn func() error) {
+	if !tx.isDone() {
+		tx.onCommit = append(tx.onCommit, fn)
+	}
+}
+
+/*
+  Register fn to run after the transaction rolled back or the commit failed, the callbacks run in the order of registration,
+  nested: after ROLLBACK TO SAVEPOINT, or the outermost transaction rolled back,
+  their errors are returned by Rollback or Commit as a *TxCallbackError, fn is ignored if the transaction is done
+*/
+func (tx *Tx) OnRollback(fn func() error) {
+	if !tx.isDone() {
+		tx.onRollback = append(tx.onRollback, fn)
+	}
+}
+
+/*
+  Run the commit or the rollback callbacks once, all of them run, the errors are aggregated
+*/
+func (tx *Tx) runCallbacks(committed bool) error {
+	callbacks := tx.onRollback
+	if committed {
+		callbacks = tx.onCommit
+	}
+	tx.onCommit, tx.onRollback = nil, nil
+	var errs []error
+	for _, fn := range callbacks {
+		if err := fn(); err != nil {
+			tx.client.logError(MySQL+" tx callback failed", err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &TxCallbackError{Committed: committed, Errs: errs}
+}
+
+/*
+  The error unchanged if no callback error
+*/
+func joinCallbackError(err, callbackErr error) error {
+	if callbackErr == nil {
+		return err
+	}
+	if err == nil {
+		return callbackErr
+	}
+	return errors.Join(err, callbackErr)
+}
+
+func (tx *Tx) savepoint() string {
+	return "tsg_savepoint_" + strconv.Itoa(tx.depth)
+}
+
+func (tx *Tx) isDone() bool {
+	return tx.done || tx.root.done
+}
+
+func (tx *Tx) innermost() *Tx {
+	if n := len(tx.root.nested); n > 0 {
+		return tx.root.nested[n-1]
+	}
+	return tx.root
+}
+
+/*
+  The nested transactions must end in the reverse order of begin
+*/
+func (tx *Tx) checkInnermost() error {
+	if tx.isDone() {
+		return db.ErrTxDone
+	}
+	if innermost := tx.innermost(); innermost != tx {
+		return fmt.Errorf("%s tx of depth %d is not the innermost of depth %d", MySQL, tx.depth, innermost.depth)
+	}
+	return nil
+}
+
+func (tx *Tx) endNested() {
+	tx.done = true
+	tx.root.nested = tx.root.nested[:len(tx.root.nested)-1]
+}
+
+/*
+  The outermost transaction ends the open nested transactions, their callbacks run with its callbacks
+*/
+func (tx *Tx) endAllNested() {
+	for _, nested := range tx.nested {
+		nested.done = true
+		tx.onCommit = append(tx.onCommit, nested.onCommit...)
+		tx.onRollback = append(tx.onRollback, nested.onRollback...)
+	}
+	tx.nested = nil
+}
+
+/*
+  Run fn in a transaction: commit if fn returns nil, otherwise rollback and return the fn error,
+  a nested transaction if the context has an open transaction of the client, see BeginTx,
+  a panic of fn rolls back and panics again, the commit error is returned,
+  opts.Retries: run fn again in a new transaction on the retryable errors of fn or commit, not on the callback errors,
+  the errors of the rollback callbacks are joined to the fn error, see Tx.OnCommit and Tx.OnRollback
+*/
+func (client *DBClient) WithTx(ctx context.Context, opts *TxOptions, fn func(tx *Tx) error) error {
+	var retries int
+	backoff := DefaultTxRetryBackoff
+	// the deadlocks roll back the outermost transaction, only it is retried
+	if opts != nil && client.contextTx(ctx) == nil {
+		retries = opts.Retries
+		if opts.RetryBackoff > 0 {
+			backoff = opts.RetryBackoff
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		err := client.runTx(ctx, opts, fn)
+		var callbackErr *TxCallbackError
+		if err == nil || attempt >= retries || !IsRetryable(err) || errors.As(err, &callbackErr) {
+			return err
+		}
+		client.log(LevelWarn, "tx retry", LogField{LogKeyHost, client.Config.DbHost}, LogField{LogKeyDbName, client.Config.DbName},
+			LogField{"attempt", attempt + 1}, LogField{LogKeyError, err})
+		// half of the backoff is jittered to spread the retries of the deadlocked transactions
+		timer := time.NewTimer(backoff/2 + rand.N(backoff/2+1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > MaxTxRetryBackoff {
+			backoff = MaxTxRetryBackoff
+		}
+	}
+}
+
+func (client *DBClient) runTx(ctx context.Context, opts *TxOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := client.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	done := false
+	defer func() {
+		if !done {
+			// fn panicked
+			tx.Rollback()
+		}
+	}()
+	err = fn(tx)
+	done = true
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		var callbackErr *TxCallbackError
+		if errors.As(rollbackErr, &callbackErr) {
+			return errors.Join(err, callbackErr)
+		}
+		if rollbackErr != nil && !tx.isDone() {
+			// the nested rollback failed, the changes are kept in the outer transaction
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil && !tx.isDone() {
+		// the nested release failed
+		tx.Rollback()
+	}
+	return err
+}
@@ -0,0 +1,335 @@
+package tsgmysqlutils
+
+/*
+ Transaction test
+ @author Tony Tian
+ @date 2026-10-18
+ @version 1.0.0
+*/
+
+import (
+	"bytes"
+	"context"
+	db "database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTx(t *testing.T) {
+	client, fake := newFakeClient(t, nil)
+	ctx := context.Background()
+	err := client.WithTx(ctx, nil, func(tx *Tx) error {
+		_, err := client.TxExecContext(ctx, tx.Tx, "DELETE FROM user WHERE id = ?", 1)
+		return err
+	})
+	if log := fake.getLog(); err != nil || strings.Join(log, "; ") != "begin isolation=0 read_only=false; exec DELETE FROM user WHERE id = ? [1]; commit" {
+		t.Error("With tx commit:", log, err)
+	}
+
+	fnErr := errors.New("fn failed")
+	fake.log = nil
+	if err = client.WithTx(ctx, &TxOptions{Retries: 3}, func(tx *Tx) error { return fnErr }); err != fnErr {
+		t.Error("With tx error:", err)
+	}
+	if log := fake.getLog(); strings.Join(log, "; ") != "begin isolation=0 read_only=false; rollback" {
+		t.Error("With tx rollback:", log)
+	}
+
+	fake.log = nil
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Error("With tx panic:", r)
+			}
+		}()
+		client.WithTx(ctx, nil, func(tx *Tx) error { panic("boom") })
+	}()
+	if log := fake.getLog(); strings.Join(log, "; ") != "begin isolation=0 read_only=false; rollback" {
+		t.Error("With tx panic rollback:", log)
+	}
+
+	// the deadlock of fn and commit are retried, the commit error is returned
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	fake.log = nil
+	fake.commitErrs = []error{deadlock, deadlock}
+	attempts := 0
+	err = client.WithTx(ctx, &TxOptions{Isolation: db.LevelSerializable, Retries: 2, RetryBackoff: time.Millisecond}, func(tx *Tx) error {
+		attempts++
+		if attempts == 1 {
+			return deadlock
+		}
+		return nil
+	})
+	if log := fake.getLog(); err != deadlock || attempts != 3 || len(log) != 6 || log[0] != "begin isolation=6 read_only=false" || log[5] != "commit" {
+		t.Error("With tx retry:", attempts, log, err)
+	}
+}
+
+func TestTxOptions(t *testing.T) {
+	client, fake := newFakeClient(t, map[string]fakeResult{
+		"SELECT @@SESSION.innodb_lock_wait_timeout, @@SESSION.max_execution_time": {
+			columns: []string{"@@SESSION.innodb_lock_wait_timeout", "@@SESSION.max_execution_time"}, rows: [][]driver.Value{{[]byte("50"), []byte("0")}}},
+	})
+	ctx := context.Background()
+	tx, err := client.BeginTx(ctx, &TxOptions{Isolation: db.LevelReadCommitted, ReadOnly: true})
+	if err != nil || tx.Commit() != nil || strings.Join(fake.getLog(), "; ") != "begin isolation=2 read_only=true; commit" {
+		t.Error("Tx read only:", fake.getLog(), err)
+	}
+
+	// the session variables are set before begin and restored after commit
+	fake.log = nil
+	opts := &TxOptions{Isolation: db.LevelSerializable, LockWaitTimeout: 2500 * time.Millisecond, Session: map[string]interface{}{"max_execution_time": 1000}}
+	err = client.WithTx(ctx, opts, func(tx *Tx) error {
+		_, err := client.TxExecContext(ctx, tx.Tx, "UPDATE ledger SET amount = amount - ? WHERE id = ?", 10, 1)
+		return err
+	})
+	expected := []string{
+		"query SELECT @@SESSION.innodb_lock_wait_timeout, @@SESSION.max_execution_time []",
+		"exec SET SESSION innodb_lock_wait_timeout = ?, max_execution_time = ? [3 1000]",
+		"begin isolation=6 read_only=false",
+		"exec UPDATE ledger SET amount = amount - ? WHERE id = ? [10 1]",
+		"commit",
+		"exec SET SESSION innodb_lock_wait_timeout = ?, max_execution_time = ? [50 0]",
+	}
+	if log := fake.getLog(); err != nil || !reflect.DeepEqual(log, expected) || client.Db.Stats().InUse != 0 {
+		t.Error("Tx session:", log, client.Db.Stats().InUse, err)
+	}
+
+	fake.log = nil
+	if _, err = client.BeginTx(ctx, &TxOptions{Session: map[string]interface{}{"sql_mode = '', x": 1}}); err == nil || len(fake.getLog()) != 0 {
+		t.Error("Tx invalid session variable:", err)
+	}
+}
+
+func TestNestedTx(t *testing.T) {
+	client, fake := newFakeClient(t, nil)
+	ctx := context.Background()
+	outer, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal("Begin tx:", err)
+	}
+	inner, err := client.BeginTx(ContextWithTx(ctx, outer), &TxOptions{ReadOnly: true})
+	if err != nil || inner.Depth() != 1 || inner.Tx != outer.Tx {
+		t.Fatal("Begin nested tx:", err)
+	}
+	client.TxExec(inner.Tx, "UPDATE user SET name = ? WHERE id = ?", "tony", 1)
+	innermost, err := client.BeginTx(ContextWithTx(ctx, outer), nil)
+	if err != nil || innermost.Depth() != 2 {
+		t.Fatal("Begin innermost tx:", err)
+	}
+	if err = inner.Commit(); err == nil || !strings.Contains(err.Error(), "not the innermost") {
+		t.Error("Commit not innermost:", err)
+	}
+	if err = innermost.Rollback(); err != nil {
+		t.Error("Rollback innermost:", err)
+	}
+	if err = inner.Commit(); err != nil || inner.Commit() != db.ErrTxDone {
+		t.Error("Commit nested:", err)
+	}
+	if err = outer.Commit(); err != nil {
+		t.Error("Commit outer:", err)
+	}
+	expected := []string{
+		"begin isolation=0 read_only=false",
+		"exec SAVEPOINT tsg_savepoint_1 []",
+		"exec UPDATE user SET name = ? WHERE id = ? [tony 1]",
+		"exec SAVEPOINT tsg_savepoint_2 []",
+		"exec ROLLBACK TO SAVEPOINT tsg_savepoint_2 []",
+		"exec RELEASE SAVEPOINT tsg_savepoint_2 []",
+		"exec RELEASE SAVEPOINT tsg_savepoint_1 []",
+		"commit",
+	}
+	if log := fake.getLog(); !reflect.DeepEqual(log, expected) {
+		t.Error("Nested tx:", log)
+	}
+
+	// the failed nested WithTx is rolled back to the savepoint, the outer commits
+	fake.log = nil
+	fnErr := errors.New("coupon used")
+	err = client.WithTx(ctx, nil, func(tx *Tx) error {
+		ctx := ContextWithTx(ctx, tx)
+		if err := client.WithTx(ctx, &TxOptions{Retries: 3}, func(tx *Tx) error { return fnErr }); err != fnErr {
+			t.Error("Nested with tx:", err)
+		}
+		return nil
+	})
+	expected = []string{"begin isolation=0 read_only=false", "exec SAVEPOINT tsg_savepoint_1 []",
+		"exec ROLLBACK TO SAVEPOINT tsg_savepoint_1 []", "exec RELEASE SAVEPOINT tsg_savepoint_1 []", "commit"}
+	if log := fake.getLog(); err != nil || !reflect.DeepEqual(log, expected) {
+		t.Error("Nested with tx:", log, err)
+	}
+	if _, err = outer.Begin(ctx); err != db.ErrTxDone {
+		t.Error("Begin done tx:", err)
+	}
+	// the savepoint statements use the context of the begin, the failed nested rollback keeps the transaction open
+	t.Run("RollbackFailed", func(t *testing.T) {
+		type beginKey struct{}
+		rollbackErr := errors.New("rollback failed")
+		client, fake := newFakeClient(t, map[string]fakeResult{"ROLLBACK TO SAVEPOINT tsg_savepoint_1": {err: rollbackErr}})
+		var contexts []interface{}
+		client.AddHook(HookFuncs{
+			Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+				contexts = append(contexts, ctx.Value(beginKey{}))
+				return ctx, nil
+			},
+		})
+		outer, err := client.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal("Begin tx:", err)
+		}
+		inner, err := client.BeginTx(context.WithValue(ContextWithTx(ctx, outer), beginKey{}, "inner"), nil)
+		if err != nil {
+			t.Fatal("Begin nested tx:", err)
+		}
+		rolledBack := false
+		inner.OnRollback(func() error {
+			rolledBack = true
+			return nil
+		})
+		if err = inner.Rollback(); !errors.Is(err, rollbackErr) || inner.isDone() || rolledBack {
+			t.Error("Rollback nested failed:", err, rolledBack)
+		}
+		if err = inner.Commit(); err != nil || rolledBack {
+			t.Error("Commit nested after rollback failed:", err)
+		}
+		outer.Rollback()
+		expected := []string{"begin isolation=0 read_only=false", "exec SAVEPOINT tsg_savepoint_1 []",
+			"exec ROLLBACK TO SAVEPOINT tsg_savepoint_1 []", "exec RELEASE SAVEPOINT tsg_savepoint_1 []", "rollback"}
+		if log := fake.getLog(); !rolledBack || !reflect.DeepEqual(log, expected) {
+			t.Error("Rollback nested failed log:", log, rolledBack)
+		}
+		if fmt.Sprint(contexts) != "[inner inner inner]" {
+			t.Error("Savepoint contexts:", contexts)
+		}
+
+		// WithTx returns the failed nested rollback with the fn error
+		fnErr := errors.New("coupon used")
+		err = client.WithTx(ctx, nil, func(tx *Tx) error {
+			err := client.WithTx(ContextWithTx(ctx, tx), nil, func(tx *Tx) error { return fnErr })
+			if !errors.Is(err, fnErr) || !errors.Is(err, rollbackErr) {
+				t.Error("Nested with tx rollback failed:", err)
+			}
+			return err
+		})
+		if !errors.Is(err, fnErr) {
+			t.Error("With tx rollback failed:", err)
+		}
+	})
+}
+
+func TestTxCallbacks(t *testing.T) {
+	releaseErr := errors.New("release failed")
+	client, fake := newFakeClient(t, map[string]fakeResult{"RELEASE SAVEPOINT tsg_savepoint_2": {err: releaseErr}})
+	ctx := context.Background()
+	var calls []string
+	callback := func(name string, err error) func() error {
+		return func() error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	// commit: the commit callbacks in order, the nested rolled back callbacks discarded
+	cacheErr := errors.New("cache down")
+	err := client.WithTx(ctx, nil, func(tx *Tx) error {
+		tx.OnCommit(callback("publish", nil))
+		tx.OnRollback(callback("outer rollback", nil))
+		tx.WithTx(ctx, nil, func(tx *Tx) error {
+			tx.OnCommit(callback("coupon used", nil))
+			tx.OnRollback(callback("coupon rollback", nil))
+			return errors.New("coupon expired")
+		})
+		if len(calls) != 1 || calls[0] != "coupon rollback" {
+			t.Error("Nested rollback callbacks:", calls)
+		}
+		tx.WithTx(ctx, nil, func(tx *Tx) error {
+			tx.OnCommit(callback("invalidate", cacheErr))
+			return nil
+		})
+		tx.OnCommit(callback("notify", nil))
+		if len(calls) != 1 {
+			t.Error("Callbacks before commit:", calls)
+		}
+		return nil
+	})
+	var callbackErr *TxCallbackError
+	if !errors.As(err, &callbackErr) || !callbackErr.Committed || !errors.Is(err, cacheErr) {
+		t.Error("Commit callback error:", err)
+	}
+	if expected := []string{"coupon rollback", "publish", "invalidate", "notify"}; !reflect.DeepEqual(calls, expected) {
+		t.Error("Commit callbacks:", calls)
+	}
+
+	// rollback: the rollback callbacks only, joined to the fn error, not retried
+	calls = nil
+	fake.log = nil
+	fnErr := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	stockErr := errors.New("stock down")
+	err = client.WithTx(ctx, &TxOptions{Retries: 3, RetryBackoff: time.Millisecond}, func(tx *Tx) error {
+		tx.OnCommit(callback("publish", nil))
+		tx.OnRollback(callback("release stock", stockErr))
+		tx.OnRollback(callback("log", nil))
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || !errors.Is(err, stockErr) || !reflect.DeepEqual(calls, []string{"release stock", "log"}) {
+		t.Error("Rollback callbacks:", calls, err)
+	}
+	if log := fake.getLog(); len(log) != 2 || log[1] != "rollback" {
+		t.Error("Rollback callbacks retried:", log)
+	}
+
+	// commit failed: the rollback callbacks
+	calls = nil
+	fake.commitErrs = []error{driver.ErrBadConn}
+	tx, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal("Begin tx:", err)
+	}
+	tx.OnCommit(callback("publish", nil))
+	tx.OnRollback(callback("release stock", nil))
+	if err = tx.Commit(); err == nil || !reflect.DeepEqual(calls, []string{"release stock"}) {
+		t.Error("Commit failed callbacks:", calls, err)
+	}
+	tx.OnRollback(callback("done", nil))
+	if err = tx.Rollback(); err != db.ErrTxDone || len(calls) != 1 {
+		t.Error("Done tx callbacks:", calls, err)
+	}
+
+	// the failed release keeps the nested open, the open nested callbacks run with the outermost
+	calls = nil
+	outer, _ := client.BeginTx(ctx, nil)
+	inner, _ := outer.Begin(ctx)
+	inner.OnCommit(callback("inner", nil))
+	failed, _ := inner.Begin(ctx)
+	failed.OnCommit(callback("failed", nil))
+	if err = failed.Commit(); !errors.Is(err, releaseErr) || failed.isDone() || outer.innermost() != failed {
+		t.Error("Release failed:", err)
+	}
+	failed.Rollback()
+	open, _ := inner.Begin(ctx)
+	open.OnCommit(callback("open", nil))
+	if err = outer.Commit(); err != nil || !reflect.DeepEqual(calls, []string{"inner", "open"}) {
+		t.Error("Open nested callbacks:", calls, err)
+	}
+	if err = open.Commit(); err != db.ErrTxDone {
+		t.Error("Open nested done:", err)
+	}
+
+	// the deferred rollback after commit: no driver rollback, no error log
+	var buffer bytes.Buffer
+	client.Logger = NewStdLogger(log.New(&buffer, "", 0))
+	tx, _ = client.BeginTx(ctx, nil)
+	if err = tx.Commit(); err != nil {
+		t.Error("Commit:", err)
+	}
+	if tx.Rollback() != db.ErrTxDone || tx.Commit() != db.ErrTxDone || buffer.Len() != 0 {
+		t.Error("Rollback after commit:", buffer.String())
+	}
+}